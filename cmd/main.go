@@ -23,7 +23,7 @@ func main() {
 
 	log.Info("Starting application", slog.Any("cfg", cfg))
 
-	application := app.New(log, cfg.Server.Port, cfg.ConnectionString, cfg.TokenTTL)
+	application := app.New(log, cfg)
 
 	go application.HTTPSrv.MustRun()
 
@@ -35,7 +35,7 @@ func main() {
 
 	log.Info("stopping application", slog.String("signal", sign.String()))
 
-	application.HTTPSrv.Stop()
+	application.Stop()
 
 	log.Info("application stopped")
 }
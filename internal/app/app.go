@@ -1,37 +1,139 @@
 package app
 
 import (
+	"context"
 	"log/slog"
+	"net/http"
 	httppapp "passion-pals-backend/internal/app/httpapp"
+	"passion-pals-backend/internal/config"
+	"passion-pals-backend/internal/controllers/admin"
+	"passion-pals-backend/internal/controllers/apikeys"
 	"passion-pals-backend/internal/controllers/auth"
+	"passion-pals-backend/internal/controllers/export"
+	"passion-pals-backend/internal/controllers/interests"
+	"passion-pals-backend/internal/controllers/photos"
 	"passion-pals-backend/internal/controllers/profile"
+	"passion-pals-backend/internal/controllers/security"
 	"passion-pals-backend/internal/repository"
-	"time"
+	apikeystore "passion-pals-backend/internal/utils/apikeys"
+	"passion-pals-backend/internal/utils/audit"
+	"passion-pals-backend/internal/utils/deletion"
+	dataexport "passion-pals-backend/internal/utils/export"
+	"passion-pals-backend/internal/utils/images"
+	"passion-pals-backend/internal/utils/keyring"
+	"passion-pals-backend/internal/utils/mailer"
+	"passion-pals-backend/internal/utils/oidc"
+	"passion-pals-backend/internal/utils/password"
+	"passion-pals-backend/internal/utils/revocation"
+	"passion-pals-backend/internal/utils/sessions"
+	"passion-pals-backend/internal/utils/storage"
+	"passion-pals-backend/internal/utils/throttle"
+	"passion-pals-backend/internal/utils/validation"
 )
 
 type App struct {
 	HTTPSrv *httppapp.App
+
+	// stopJobs останавливает фоновые задачи
+	stopJobs context.CancelFunc
 }
 
 func New(
 	log *slog.Logger,
-	httpPort int,
-	connStr string,
-	tokenTTL time.Duration,
+	cfg *config.Config,
 ) *App {
 
-	repo, err := repository.NewRepository(connStr)
+	repo, err := repository.NewRepository(cfg.ConnectionString)
 
 	if err != nil {
 		panic(err)
 	}
 
-	authService := auth.New(log, repo, tokenTTL)
-	profileService := profile.New(log, repo)
+	keys, err := keyring.New(cfg.JWT)
+	if err != nil {
+		panic(err)
+	}
 
-	httpApp := httppapp.New(log, authService, profileService, httpPort)
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		panic(err)
+	}
+
+	providers, err := oidc.NewProviders(cfg.OIDC)
+	if err != nil {
+		panic(err)
+	}
+
+	policy, err := validation.NewPolicy(cfg.Registration)
+	if err != nil {
+		panic(err)
+	}
+
+	passwords, err := password.New(cfg.Password)
+	if err != nil {
+		panic(err)
+	}
+
+	exporter, err := dataexport.New(log, repo, cfg.Export)
+	if err != nil {
+		panic(err)
+	}
+
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		panic(err)
+	}
+
+	processor, err := images.New(cfg.Photos)
+	if err != nil {
+		panic(err)
+	}
+
+	// Файлы локального хранилища раздает сам API
+	var media http.Handler
+	if local, ok := store.(*storage.LocalStorage); ok {
+		media = local.Handler()
+	}
+
+	revoked := revocation.New(log, repo, cfg.Revocation.SyncInterval, cfg.Revocation.PurgeInterval)
+	if err := revoked.Load(context.Background()); err != nil {
+		panic(err)
+	}
+	tracker := sessions.New(log, repo, cfg.Sessions.TouchInterval, cfg.Sessions.PurgeInterval)
+	keyStore := apikeystore.New(log, repo, cfg.APIKeys.TouchInterval)
+	limiter := throttle.New(log, repo, cfg.Auth.Lockout)
+	purger := deletion.New(log, repo, cfg.AccountDeletion)
+	events := audit.New(log, repo)
+	cleaner := storage.NewCleaner(log, repo, store, cfg.Photos.CleanupInterval)
+
+	authService := auth.New(log, repo, keys, revoked, tracker, mail, limiter, providers, policy, passwords, events, cfg.Auth, cfg.TokenTTL, cfg.RefreshTokenTTL)
+	profileService := profile.New(log, repo, revoked, purger, events, policy, passwords, limiter, cfg.Feed, cfg.Recommendations, cfg.TokenTTL)
+	adminService := admin.New(log, repo, revoked, events, cfg.TokenTTL)
+	exportService := export.New(log, repo, exporter)
+	apiKeysService := apikeys.New(log, repo, keyStore, events, cfg.APIKeys)
+	securityService := security.New(log, repo)
+	interestsService := interests.New(log, repo)
+	photosService := photos.New(log, repo, store, processor, cfg.Photos)
+
+	httpApp := httppapp.New(log, authService, profileService, adminService, apiKeysService, exportService, securityService, interestsService, photosService, keys, revoked, tracker, keyStore, media, cfg.Server.TrustedProxies, cfg.Server.Port)
+
+	// Фоновые задачи
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	go revoked.Run(jobsCtx)
+	go tracker.Run(jobsCtx)
+	go purger.Run(jobsCtx)
+	go exporter.Run(jobsCtx)
+	go limiter.Run(jobsCtx)
+	go cleaner.Run(jobsCtx)
 
 	return &App{
-		HTTPSrv: httpApp,
+		HTTPSrv:  httpApp,
+		stopJobs: stopJobs,
 	}
 }
+
+// Stop останавливает HTTP-сервер и фоновые задачи
+func (a *App) Stop() {
+	a.HTTPSrv.Stop()
+	a.stopJobs()
+}
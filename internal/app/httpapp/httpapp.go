@@ -4,8 +4,20 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	adminhttp "passion-pals-backend/internal/http/admin"
+	apikeyshttp "passion-pals-backend/internal/http/apikeys"
 	authhttp "passion-pals-backend/internal/http/auth" // Предположим, что у вас есть HTTP-хендлеры для auth
+	exporthttp "passion-pals-backend/internal/http/export"
+	interestshttp "passion-pals-backend/internal/http/interests"
+	photoshttp "passion-pals-backend/internal/http/photos"
 	profilehttp "passion-pals-backend/internal/http/profile"
+	securityhttp "passion-pals-backend/internal/http/security"
+	"passion-pals-backend/internal/utils/apikeys"
+	"passion-pals-backend/internal/utils/keyring"
+	"passion-pals-backend/internal/utils/middleware"
+	"passion-pals-backend/internal/utils/rbac"
+	"passion-pals-backend/internal/utils/revocation"
+	"passion-pals-backend/internal/utils/sessions"
 
 	"github.com/gin-contrib/cors"
 
@@ -22,22 +34,61 @@ func New(
 	log *slog.Logger,
 	authService authhttp.Auth,
 	profileService profilehttp.Profile, // Предположим, что у вас есть HTTP-хендлер для auth
+	adminService adminhttp.Admin,
+	apiKeysService apikeyshttp.APIKeys,
+	exportService exporthttp.Export,
+	securityService securityhttp.Security,
+	interestsService interestshttp.Interests,
+	photosService photoshttp.Photos,
+	keys *keyring.Keyring,
+	revoked *revocation.List,
+	sessions *sessions.Tracker,
+	apiKeys *apikeys.Store,
+	media http.Handler, // Раздача файлов локального хранилища, nil - файлы раздает внешнее хранилище
+	trustedProxies []string,
 	port int,
 ) *App {
 	// Инициализация Gin
-	router := gin.Default()
+	router, err := newRouter(trustedProxies)
+	if err != nil {
+		panic(err)
+	}
 
 	//Настройка разрешенных источников и методов запроса TODO - вынести в отдельный метод
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:5173"}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "PATCH"}
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "If-Match", middleware.RequestIDHeader}
+	config.ExposeHeaders = []string{"ETag", "Location", middleware.RequestIDHeader}
+	// Cookie с nonce ссылки для входа выставляется в ответ на запрос фронтенда
+	config.AllowCredentials = true
 
 	router.Use(cors.New(config))
+	router.Use(middleware.RequestID())
+
+	// authMiddleware принимает JWT и API-ключи, sessionMiddleware - только JWT интерактивной сессии
+	authMiddleware := middleware.AuthMiddleware(keys, revoked, sessions, apiKeys)
+	sessionMiddleware := middleware.AuthMiddleware(keys, revoked, sessions, nil)
+
+	// Администрирование доступно только администраторам с областью доступа admin
+	adminGuards := []gin.HandlerFunc{
+		middleware.RequireRole(rbac.RoleAdmin),
+		middleware.RequireScope(rbac.ScopeAdmin),
+	}
 
 	// Регистрация HTTP-хендлеров
-	authhttp.Register(router, authService)
-	profilehttp.Register(router, profileService)
+	authhttp.Register(router, authService, sessionMiddleware)
+	profilehttp.Register(router, profileService, authMiddleware, sessionMiddleware)
+	adminhttp.Register(router, adminService, authMiddleware, adminGuards...)
+	apikeyshttp.Register(router, apiKeysService, sessionMiddleware, adminGuards...)
+	exporthttp.Register(router, exportService, sessionMiddleware)
+	securityhttp.Register(router, securityService, sessionMiddleware, adminGuards...)
+	interestshttp.Register(router, interestsService, authMiddleware, adminGuards...)
+	photoshttp.Register(router, photosService, authMiddleware)
+
+	if media != nil {
+		router.GET("/media/*key", gin.WrapH(http.StripPrefix("/media", media)))
+	}
 
 	return &App{
 		log:    log,
@@ -46,6 +97,17 @@ func New(
 	}
 }
 
+// newRouter gin с доверием X-Forwarded-For только от trustedProxies.
+// Иначе подделанный заголовок меняет c.ClientIP(), а с ним ключ блокировки по IP и адреса в сессиях и журнале.
+func newRouter(trustedProxies []string) (*gin.Engine, error) {
+	router := gin.Default()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("failed to set trusted proxies: %w", err)
+	}
+
+	return router, nil
+}
+
 func (a *App) MustRun() {
 	if err := a.Run(); err != nil {
 		panic(err)
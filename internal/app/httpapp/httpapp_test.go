@@ -0,0 +1,52 @@
+package httppapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Адрес клиента (он же ключ блокировки по IP) берется из X-Forwarded-For только за доверенным прокси
+func TestClientIPTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		want           string
+	}{
+		{"no trusted proxies", nil, "203.0.113.7"},
+		{"request not from trusted proxy", []string{"10.0.0.0/8"}, "203.0.113.7"},
+		{"trusted proxy", []string{"203.0.113.0/24"}, "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := newRouter(tt.trustedProxies)
+			if err != nil {
+				t.Fatal(err)
+			}
+			router.GET("/ip", func(c *gin.Context) {
+				c.String(http.StatusOK, c.ClientIP())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = "203.0.113.7:51234"
+			req.Header.Set("X-Forwarded-For", "198.51.100.1")
+			req.Header.Set("X-Real-IP", "198.51.100.1")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("client ip = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := newRouter([]string{"not an ip"}); err == nil {
+		t.Error("invalid proxy accepted")
+	}
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"encoding/json"
 	"flag"
+	"log/slog"
 	"os"
 	"time"
 
@@ -9,15 +11,287 @@ import (
 )
 
 type Config struct {
-	Env              string        `yaml:"env" env-defolt:"local"`
-	ConnectionString string        `yaml:"connection_string" env-required:"./data"`
-	TokenTTL         time.Duration `yaml:"token_ttl" env-required:"true"`
-	Server           ServerConfig  `yaml:"server"`
+	Env              string                `yaml:"env" env-defolt:"local"`
+	ConnectionString string                `yaml:"connection_string" env-required:"./data"`
+	TokenTTL         time.Duration         `yaml:"token_ttl" env-required:"true"`
+	RefreshTokenTTL  time.Duration         `yaml:"refresh_token_ttl" env-default:"720h"`
+	Server           ServerConfig          `yaml:"server"`
+	JWT              JWTConfig             `yaml:"jwt"`
+	Revocation       RevocationConfig      `yaml:"revocation"`
+	Sessions         SessionsConfig        `yaml:"sessions"`
+	Auth             AuthConfig            `yaml:"auth"`
+	Mail             MailConfig            `yaml:"mail"`
+	OIDC             OIDCConfig            `yaml:"oidc"`
+	Registration     RegistrationConfig    `yaml:"registration"`
+	Password         PasswordConfig        `yaml:"password"`
+	APIKeys          APIKeysConfig         `yaml:"api_keys"`
+	AccountDeletion  AccountDeletionConfig `yaml:"account_deletion"`
+	Export           ExportConfig          `yaml:"export"`
+	Feed             FeedConfig            `yaml:"feed"`
+	Recommendations  RecommendationsConfig `yaml:"recommendations"`
+	Photos           PhotosConfig          `yaml:"photos"`
+	Storage          StorageConfig         `yaml:"storage"`
 }
 
 type ServerConfig struct {
 	Port    int           `yaml:"port"`
 	Timeout time.Duration `yaml:"timeout"`
+	// TrustedProxies адреса и подсети прокси, которым доверяется X-Forwarded-For.
+	// По умолчанию никому: адрес клиента берется из соединения.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// AuthConfig настройки регистрации и входа
+type AuthConfig struct {
+	// EmailVerificationTTL срок действия ссылки подтверждения email
+	EmailVerificationTTL time.Duration `yaml:"email_verification_ttl" env-default:"24h"`
+	// VerifyEmailURL страница фронтенда, на которую ведет ссылка из письма
+	VerifyEmailURL string `yaml:"verify_email_url" env-default:"http://localhost:5173/verify-email"`
+	// PasswordResetTTL срок действия ссылки сброса пароля
+	PasswordResetTTL time.Duration `yaml:"password_reset_ttl" env-default:"30m"`
+	// ResetPasswordURL страница фронтенда для ввода нового пароля
+	ResetPasswordURL string `yaml:"reset_password_url" env-default:"http://localhost:5173/reset-password"`
+	// MFAChallengeTTL время на ввод кода 2FA после проверки пароля
+	MFAChallengeTTL time.Duration `yaml:"mfa_challenge_ttl" env-default:"5m"`
+	// TOTPIssuer название сервиса в приложении-аутентификаторе
+	TOTPIssuer string `yaml:"totp_issuer" env-default:"Passion Pals"`
+	// MagicLinkTTL срок действия ссылки для входа без пароля
+	MagicLinkTTL time.Duration `yaml:"magic_link_ttl" env-default:"15m"`
+	// MagicLinkURL адрес API для перехода по ссылке: cookie с nonce выставлена на домен API
+	MagicLinkURL string `yaml:"magic_link_url" env-default:"http://localhost:44044/login/magic/verify"`
+	// Lockout защита от перебора паролей
+	Lockout LockoutConfig `yaml:"lockout"`
+}
+
+// LockoutConfig пороги блокировки входа. После MaxAccountFailures (MaxIPFailures)
+// неудач подряд вход блокируется на BaseDelay, каждая следующая неудача удваивает
+// блокировку вплоть до MaxDelay. Счетчик сбрасывается, если неудач не было дольше Window.
+type LockoutConfig struct {
+	MaxAccountFailures int           `yaml:"max_account_failures" env-default:"5"`
+	MaxIPFailures      int           `yaml:"max_ip_failures" env-default:"20"`
+	Window             time.Duration `yaml:"window" env-default:"15m"`
+	BaseDelay          time.Duration `yaml:"base_delay" env-default:"1m"`
+	MaxDelay           time.Duration `yaml:"max_delay" env-default:"1h"`
+	// MaxMagicLinks сколько ссылок для входа можно запросить на один адрес за Window
+	MaxMagicLinks int `yaml:"max_magic_links" env-default:"3"`
+	// MaxEmailRequests сколько писем одного вида (подтверждение email, сброс пароля) можно запросить на один адрес за Window
+	MaxEmailRequests int `yaml:"max_email_requests" env-default:"3"`
+	// MaxIPEmailRequests сколько таких писем можно запросить с одного IP за Window
+	MaxIPEmailRequests int `yaml:"max_ip_email_requests" env-default:"20"`
+}
+
+// RegistrationConfig правила проверки данных при регистрации
+type RegistrationConfig struct {
+	MinPasswordLength int      `yaml:"min_password_length" env-default:"8"`
+	MinAge            int      `yaml:"min_age" env-default:"18"`
+	AllowedGenders    []string `yaml:"allowed_genders" env-default:"male,female"`
+	// BreachedPasswordsFile список утекших/популярных паролей, по одному в строке
+	BreachedPasswordsFile string `yaml:"breached_passwords_file"`
+}
+
+// PasswordConfig алгоритм хэширования паролей. Хэши с другим алгоритмом или
+// устаревшими параметрами прозрачно перехэшируются при успешном входе.
+type PasswordConfig struct {
+	// Algorithm bcrypt или argon2id
+	Algorithm  string       `yaml:"algorithm" env-default:"bcrypt"`
+	BcryptCost int          `yaml:"bcrypt_cost" env-default:"12"`
+	Argon2     Argon2Config `yaml:"argon2"`
+}
+
+// Argon2Config параметры Argon2id (memory в KiB)
+type Argon2Config struct {
+	Memory      uint32 `yaml:"memory" env-default:"65536"`
+	Iterations  uint32 `yaml:"iterations" env-default:"3"`
+	Parallelism uint8  `yaml:"parallelism" env-default:"2"`
+	SaltLength  uint32 `yaml:"salt_length" env-default:"16"`
+	KeyLength   uint32 `yaml:"key_length" env-default:"32"`
+}
+
+// APIKeysConfig срок действия API-ключей
+type APIKeysConfig struct {
+	DefaultTTL time.Duration `yaml:"default_ttl" env-default:"2160h"`
+	MaxTTL     time.Duration `yaml:"max_ttl" env-default:"8760h"`
+	// TouchInterval как часто обновлять время последнего использования ключа
+	TouchInterval time.Duration `yaml:"touch_interval" env-default:"1m"`
+}
+
+// AccountDeletionConfig мягкое удаление учетных записей
+type AccountDeletionConfig struct {
+	// GracePeriod сколько учетную запись можно восстановить, прежде чем данные будут удалены
+	GracePeriod   time.Duration `yaml:"grace_period" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// ExportConfig выгрузка персональных данных
+type ExportConfig struct {
+	// Dir каталог для готовых архивов
+	Dir string `yaml:"dir" env-default:"./tmp/exports"`
+	// TTL сколько архив доступен для скачивания
+	TTL          time.Duration `yaml:"ttl" env-default:"72h"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"10s"`
+	// StaleAfter через сколько зависшая выгрузка берется в работу повторно
+	StaleAfter    time.Duration `yaml:"stale_after" env-default:"15m"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// FeedConfig лента анкет GET /profiles
+type FeedConfig struct {
+	DefaultPageSize int `yaml:"default_page_size" env-default:"20"`
+	MaxPageSize     int `yaml:"max_page_size" env-default:"100"`
+	// DefaultActiveWithin окно активности, если в запросе не указан active_within
+	DefaultActiveWithin time.Duration `yaml:"default_active_within" env-default:"168h"`
+	// MaxWithinKm наибольший радиус поиска within_km
+	MaxWithinKm int `yaml:"max_within_km" env-default:"500"`
+	// LocationChangeInterval как часто можно менять координаты анкеты
+	LocationChangeInterval time.Duration `yaml:"location_change_interval" env-default:"1h"`
+}
+
+// RecommendationsConfig ранжирование ленты GET /profiles/recommended.
+// Итоговая оценка - сумма компонент (каждая от 0 до 1), умноженных на веса.
+type RecommendationsConfig struct {
+	Weights RecommendationWeights `yaml:"weights"`
+	// MaxAgeGap разница в возрасте, при которой совпадение по возрасту становится нулевым
+	MaxAgeGap int `yaml:"max_age_gap" env-default:"10"`
+	// ActivityHalfLife через сколько после обновления анкеты оценка активности падает вдвое
+	ActivityHalfLife time.Duration `yaml:"activity_half_life" env-default:"72h"`
+	// CandidatePoolSize сколько недавно активных анкет оценивается на один запрос
+	CandidatePoolSize int `yaml:"candidate_pool_size" env-default:"500"`
+	DefaultPageSize   int `yaml:"default_page_size" env-default:"20"`
+	MaxPageSize       int `yaml:"max_page_size" env-default:"50"`
+}
+
+// RecommendationWeights веса компонент оценки рекомендаций
+type RecommendationWeights struct {
+	SharedInterests float64 `yaml:"shared_interests" env-default:"0.4"`
+	Preferences     float64 `yaml:"preferences" env-default:"0.3"`
+	Activity        float64 `yaml:"activity" env-default:"0.2"`
+	Completeness    float64 `yaml:"completeness" env-default:"0.1"`
+}
+
+// PhotosConfig галерея фотографий анкеты
+type PhotosConfig struct {
+	// MaxPhotos сколько фотографий можно держать в галерее
+	MaxPhotos int `yaml:"max_photos" env-default:"6"`
+	// MaxUploadSize наибольший размер загружаемого файла в байтах
+	MaxUploadSize int64 `yaml:"max_upload_size" env-default:"10485760"`
+	// MaxPixels наибольшее разрешение исходного изображения (ширина * высота)
+	MaxPixels int `yaml:"max_pixels" env-default:"40000000"`
+	// Sizes наибольшая сторона сохраняемых копий в пикселях; исходник не сохраняется
+	Sizes []int `yaml:"sizes" env-default:"160,480,1280"`
+	// AvatarSize копия, адрес которой становится аватаром для основной фотографии
+	AvatarSize  int `yaml:"avatar_size" env-default:"480"`
+	JPEGQuality int `yaml:"jpeg_quality" env-default:"85"`
+	// CleanupInterval как часто удалять из хранилища файлы удаленных фотографий
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env-default:"1m"`
+}
+
+// StorageConfig хранилище загруженных файлов
+type StorageConfig struct {
+	Driver string             `yaml:"driver" env-default:"local"` // local или s3
+	Local  LocalStorageConfig `yaml:"local"`
+	S3     S3StorageConfig    `yaml:"s3"`
+}
+
+type LocalStorageConfig struct {
+	Dir string `yaml:"dir" env-default:"./tmp/media"`
+	// PublicURL адрес, по которому API раздает файлы каталога (маршрут /media)
+	PublicURL string `yaml:"public_url" env-default:"http://localhost:44044/media"`
+}
+
+// S3StorageConfig S3-совместимое хранилище (AWS S3, MinIO и т.п.)
+type S3StorageConfig struct {
+	Endpoint        string `yaml:"endpoint"` // например, https://s3.eu-central-1.amazonaws.com или http://localhost:9000
+	Region          string `yaml:"region" env-default:"us-east-1"`
+	Bucket          string `yaml:"bucket"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey Secret `yaml:"secret_access_key" env:"S3_SECRET_ACCESS_KEY"`
+	// PathStyle bucket в пути (endpoint/bucket/key), а не в имени хоста; нужен для MinIO
+	PathStyle bool `yaml:"path_style" env-default:"true"`
+	// PublicURL адрес, по которому клиенты получают файлы (CDN или публичный bucket).
+	// Если не задан, используется адрес объекта в хранилище.
+	PublicURL string `yaml:"public_url"`
+}
+
+// OIDCConfig провайдеры входа через OpenID Connect ("Войти через ...")
+type OIDCConfig struct {
+	// RedirectBaseURL публичный адрес API; callback: {base}/oauth/{name}/callback
+	RedirectBaseURL string               `yaml:"redirect_base_url" env-default:"http://localhost:44044"`
+	Providers       []OIDCProviderConfig `yaml:"providers"`
+}
+
+type OIDCProviderConfig struct {
+	Name         string   `yaml:"name"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret Secret   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes"`
+}
+
+// MailConfig настройки отправки писем
+type MailConfig struct {
+	Driver string     `yaml:"driver" env-default:"file"` // smtp, file или memory
+	From   string     `yaml:"from" env-default:"Passion Pals <no-reply@passion-pals.local>"`
+	Dir    string     `yaml:"dir" env-default:"./tmp/mail"` // каталог для драйвера file
+	SMTP   SMTPConfig `yaml:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port" env-default:"587"`
+	Username string `yaml:"username"`
+	Password Secret `yaml:"password" env:"SMTP_PASSWORD"`
+}
+
+// SessionsConfig настройки учета сессий (устройств)
+type SessionsConfig struct {
+	// TouchInterval как часто обновлять время последней активности сессии в базе
+	TouchInterval time.Duration `yaml:"touch_interval" env-default:"1m"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// RevocationConfig настройки списка отозванных токенов
+type RevocationConfig struct {
+	SyncInterval  time.Duration `yaml:"sync_interval" env-default:"10s"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// JWTConfig набор ключей подписи токенов.
+// Для ротации новый ключ добавляется в keys и становится signing_key,
+// а старый остаётся в keys, пока не истекут подписанные им токены.
+type JWTConfig struct {
+	Issuer     string   `yaml:"issuer" env-default:"passion-pals"`
+	Audience   string   `yaml:"audience" env-default:"passion-pals-api"`
+	SigningKey string   `yaml:"signing_key" env-required:"true"`
+	Keys       []JWTKey `yaml:"keys"`
+}
+
+type JWTKey struct {
+	KID            string `yaml:"kid"`
+	Algorithm      string `yaml:"alg"` // HS256, RS256 или EdDSA
+	Secret         Secret `yaml:"secret"`
+	SecretEnv      string `yaml:"secret_env"`
+	PrivateKeyFile string `yaml:"private_key_file"`
+	PublicKeyFile  string `yaml:"public_key_file"`
+}
+
+// Secret строка, которая не выводится в логи
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "******"
+}
+
+// LogValue маскирует значение в slog: обработчики не вызывают String()
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// MarshalJSON маскирует значение, когда конфиг целиком сериализуется в JSON (в том числе JSONHandler)
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
 }
 
 func MustLoad() *Config {
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// Секреты не должны попадать в логи ни текстовым, ни JSON-обработчиком
+func TestSecretLogging(t *testing.T) {
+	cfg := &Config{
+		JWT:     JWTConfig{Keys: []JWTKey{{KID: "k1", Secret: "jwt-raw-secret"}}},
+		OIDC:    OIDCConfig{Providers: []OIDCProviderConfig{{Name: "google", ClientSecret: "oidc-raw-secret"}}},
+		Mail:    MailConfig{SMTP: SMTPConfig{Password: "smtp-raw-secret"}},
+		Storage: StorageConfig{S3: S3StorageConfig{SecretAccessKey: "s3-raw-secret"}},
+	}
+
+	handlers := map[string]func(buf *bytes.Buffer) slog.Handler{
+		"json": func(buf *bytes.Buffer) slog.Handler { return slog.NewJSONHandler(buf, nil) },
+		"text": func(buf *bytes.Buffer) slog.Handler { return slog.NewTextHandler(buf, nil) },
+	}
+
+	for name, handler := range handlers {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := slog.New(handler(&buf))
+
+			log.Info("Starting application", slog.Any("cfg", cfg))
+			log.Info("secret", slog.Any("value", Secret("attr-raw-secret")))
+
+			out := buf.String()
+			if strings.Contains(out, "raw-secret") {
+				t.Errorf("secret leaked: %s", out)
+			}
+			if !strings.Contains(out, "******") {
+				t.Errorf("secret is not masked: %s", out)
+			}
+		})
+	}
+}
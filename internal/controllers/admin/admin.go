@@ -0,0 +1,126 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/audit"
+	"passion-pals-backend/internal/utils/middleware"
+	"passion-pals-backend/internal/utils/rbac"
+	"passion-pals-backend/internal/utils/revocation"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminService struct {
+	log      *slog.Logger
+	repo     *repository.Repository
+	revoked  *revocation.List
+	events   *audit.Recorder
+	tokenTTL time.Duration
+}
+
+func New(log *slog.Logger, repo *repository.Repository, revoked *revocation.List, events *audit.Recorder, tokenTTL time.Duration) *AdminService {
+	return &AdminService{
+		log:      log,
+		repo:     repo,
+		revoked:  revoked,
+		events:   events,
+		tokenTTL: tokenTTL,
+	}
+}
+
+// GetUser возвращает учетную запись пользователя с ролями
+func (admin *AdminService) GetUser(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := admin.repo.FindUserByID(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		admin.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":                user.ID,
+		"username":          user.Username,
+		"email":             user.Email,
+		"email_verified_at": user.EmailVerifiedAt,
+		"totp_enabled":      user.TOTPEnabled,
+		"roles":             user.Roles,
+	})
+}
+
+// SetUserRoles заменяет роли пользователя. Выданные ему access-токены отзываются,
+// чтобы новые роли применились при следующем обновлении токена.
+func (admin *AdminService) SetUserRoles(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var rolesData struct {
+		Roles []string `json:"roles"`
+	}
+
+	if err := c.ShouldBindJSON(&rolesData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	roles := []string{}
+	for _, role := range rolesData.Roles {
+		if !rbac.IsRole(role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role", "role": role})
+			return
+		}
+		if !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+
+	// Администратор не может случайно лишить прав самого себя
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if ok && userClaims.UserID == userID && !slices.Contains(roles, rbac.RoleAdmin) {
+		c.JSON(http.StatusConflict, gin.H{"error": "You cannot remove your own admin role"})
+		return
+	}
+
+	found, err := admin.repo.SetUserRoles(c.Request.Context(), userID, roles)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update roles"})
+		admin.log.Error(err.Error())
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var actorID int
+	if userClaims != nil {
+		actorID = userClaims.UserID
+	}
+	admin.events.RecordByActor(c, audit.EventRolesChanged, userID, actorID, gin.H{"roles": roles})
+
+	if err := admin.revoked.RevokeUser(c.Request.Context(), userID, admin.tokenTTL); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Roles updated, but failed to revoke tokens"})
+		admin.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Roles updated successfully", "roles": roles})
+}
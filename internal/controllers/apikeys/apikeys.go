@@ -0,0 +1,234 @@
+package apikeys
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"passion-pals-backend/internal/config"
+	models "passion-pals-backend/internal/models"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/apikeys"
+	"passion-pals-backend/internal/utils/audit"
+	"passion-pals-backend/internal/utils/middleware"
+	"passion-pals-backend/internal/utils/rbac"
+	"passion-pals-backend/internal/utils/validation"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxNameLength ограничение длины названия ключа
+const maxNameLength = 100
+
+// APIKeysService управление API-ключами. Одни и те же обработчики обслуживают
+// ключи текущего пользователя (/profile/api-keys) и, для администратора,
+// ключи любого пользователя (/admin/users/:id/api-keys).
+type APIKeysService struct {
+	log    *slog.Logger
+	repo   *repository.Repository
+	store  *apikeys.Store
+	events *audit.Recorder
+	cfg    config.APIKeysConfig
+}
+
+func New(log *slog.Logger, repo *repository.Repository, store *apikeys.Store, events *audit.Recorder, cfg config.APIKeysConfig) *APIKeysService {
+	return &APIKeysService{
+		log:    log,
+		repo:   repo,
+		store:  store,
+		events: events,
+		cfg:    cfg,
+	}
+}
+
+// CreateAPIKey выпускает ключ. Сам ключ возвращается только в этом ответе.
+func (service *APIKeysService) CreateAPIKey(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	userID, ok := targetUserID(c, userClaims)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var keyData struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+
+	if err := c.ShouldBindJSON(&keyData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	roles, err := service.repo.GetUserRoles(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	name := strings.TrimSpace(keyData.Name)
+	ttl := service.cfg.DefaultTTL
+	if keyData.ExpiresInDays != 0 {
+		ttl = time.Duration(keyData.ExpiresInDays) * 24 * time.Hour
+	}
+
+	// Ключ не может дать больше прав, чем есть у его владельца
+	var errs validation.Errors
+	if name == "" {
+		errs.Add("name", validation.CodeRequired, "Name is required")
+	} else if utf8.RuneCountInString(name) > maxNameLength {
+		errs.Add("name", validation.CodeTooLong, fmt.Sprintf("Name must be at most %d characters long", maxNameLength))
+	}
+
+	allowed := rbac.ScopesForRoles(roles)
+	scopes := []string{}
+	for _, scope := range keyData.Scopes {
+		if !slices.Contains(allowed, scope) {
+			errs.Add("scopes", validation.CodeNotAllow, fmt.Sprintf("Scope %q is not allowed", scope))
+			continue
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(keyData.Scopes) == 0 {
+		errs.Add("scopes", validation.CodeRequired, "At least one scope is required")
+	}
+
+	if ttl <= 0 || ttl > service.cfg.MaxTTL {
+		errs.Add("expires_in_days", validation.CodeInvalid,
+			fmt.Sprintf("Expiry must be between 1 and %d days", int(service.cfg.MaxTTL.Hours()/24)))
+	}
+
+	if !errs.Empty() {
+		c.JSON(http.StatusUnprocessableEntity, errs.Response())
+		return
+	}
+
+	rawKey, prefix, keyHash, err := apikeys.Generate()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	now := time.Now()
+	apiKey := models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		Scopes:    scopes,
+		CreatedBy: userClaims.UserID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	apiKey.ID, err = service.repo.CreateAPIKey(c.Request.Context(), apiKey, keyHash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API key created, store it now: it will not be shown again",
+		"key":     rawKey,
+		"api_key": apiKey,
+	})
+}
+
+// ListAPIKeys возвращает неотозванные ключи пользователя
+func (service *APIKeysService) ListAPIKeys(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	userID, ok := targetUserID(c, userClaims)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	keys, err := service.repo.GetUserAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get API keys"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// RevokeAPIKey отзывает ключ
+func (service *APIKeysService) RevokeAPIKey(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	userID, ok := targetUserID(c, userClaims)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	keyID, err := strconv.ParseInt(c.Param("keyID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := service.repo.RevokeAPIKey(c.Request.Context(), userID, keyID); err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		service.log.Error(err.Error())
+		return
+	}
+	service.store.Forget(keyID)
+
+	service.events.RecordByActor(c, audit.EventTokensRevoked, userID, actorID(userClaims, userID),
+		gin.H{"reason": "api_key_revoked", "api_key_id": keyID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
+
+// actorID пользователь, действующий над чужими ключами (администратор), или 0
+func actorID(userClaims *middleware.Claims, userID int) int {
+	if userClaims.UserID == userID {
+		return 0
+	}
+
+	return userClaims.UserID
+}
+
+// targetUserID владелец ключей: пользователь из пути (маршруты администратора) или текущий
+func targetUserID(c *gin.Context, userClaims *middleware.Claims) (int, bool) {
+	if param := c.Param("id"); param != "" {
+		userID, err := strconv.Atoi(param)
+		return userID, err == nil
+	}
+
+	return userClaims.UserID, true
+}
@@ -1,26 +1,84 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"passion-pals-backend/internal/config"
+	models "passion-pals-backend/internal/models"
 	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/audit"
+	"passion-pals-backend/internal/utils/keyring"
+	"passion-pals-backend/internal/utils/mailer"
+	"passion-pals-backend/internal/utils/middleware"
+	"passion-pals-backend/internal/utils/oidc"
+	"passion-pals-backend/internal/utils/password"
+	"passion-pals-backend/internal/utils/rbac"
+	"passion-pals-backend/internal/utils/revocation"
+	"passion-pals-backend/internal/utils/sessions"
+	"passion-pals-backend/internal/utils/throttle"
+	"passion-pals-backend/internal/utils/validation"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type AuthService struct {
-	log  *slog.Logger
-	repo *repository.Repository
+	log             *slog.Logger
+	repo            *repository.Repository
+	keys            *keyring.Keyring
+	revoked         *revocation.List
+	sessions        *sessions.Tracker
+	mailer          mailer.Mailer
+	limiter         *throttle.Limiter
+	providers       map[string]*oidc.Provider
+	policy          *validation.Policy
+	passwords       *password.Hasher
+	events          *audit.Recorder
+	cfg             config.AuthConfig
+	tokenTTL        time.Duration
+	refreshTokenTTL time.Duration
+
+	// resetMail ограничивает число писем сброса пароля, отправляемых в фоне одновременно
+	resetMail chan struct{}
 }
 
-func New(log *slog.Logger, repo *repository.Repository, tokenTTL time.Duration) *AuthService {
+func New(
+	log *slog.Logger,
+	repo *repository.Repository,
+	keys *keyring.Keyring,
+	revoked *revocation.List,
+	sessions *sessions.Tracker,
+	mailer mailer.Mailer,
+	limiter *throttle.Limiter,
+	providers map[string]*oidc.Provider,
+	policy *validation.Policy,
+	passwords *password.Hasher,
+	events *audit.Recorder,
+	cfg config.AuthConfig,
+	tokenTTL, refreshTokenTTL time.Duration,
+) *AuthService {
 	return &AuthService{
-		log:  log,
-		repo: repo,
+		log:             log,
+		repo:            repo,
+		keys:            keys,
+		revoked:         revoked,
+		sessions:        sessions,
+		mailer:          mailer,
+		limiter:         limiter,
+		providers:       providers,
+		policy:          policy,
+		passwords:       passwords,
+		events:          events,
+		cfg:             cfg,
+		tokenTTL:        tokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+
+		resetMail: make(chan struct{}, maxPendingResetEmails),
 	}
 }
 
@@ -38,28 +96,67 @@ func (auth *AuthService) Register(c *gin.Context) {
 		return
 	}
 
-	// Проверка, что username и password не пустые
-	if strings.TrimSpace(newUser.Username) == "" || strings.TrimSpace(newUser.Password) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username and password are required"})
+	newUser.Email = validation.NormalizeEmail(newUser.Email)
+	newUser.Username = strings.TrimSpace(newUser.Username)
+	newUser.Gender = strings.ToLower(strings.TrimSpace(newUser.Gender))
+
+	// Проверка полей по политике регистрации
+	var errs validation.Errors
+	auth.policy.Username(&errs, "username", newUser.Username)
+	auth.policy.Email(&errs, "email", newUser.Email)
+	auth.policy.Password(&errs, "password", newUser.Password)
+	auth.policy.BirthDate(&errs, "birth_date", newUser.BirthDate)
+	auth.policy.Gender(&errs, "gender", newUser.Gender)
+
+	// Уникальность проверяем только для корректных значений
+	if !errs.Has("username") || !errs.Has("email") {
+		usernameTaken, emailTaken, err := auth.repo.UserExists(c.Request.Context(), newUser.Username, newUser.Email)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
+			auth.log.Error(err.Error())
+			return
+		}
+		if usernameTaken && !errs.Has("username") {
+			errs.Add("username", validation.CodeTaken, "Username is already taken")
+		}
+		if emailTaken && !errs.Has("email") {
+			errs.Add("email", validation.CodeTaken, "Email is already registered")
+		}
+	}
+
+	if !errs.Empty() {
+		c.JSON(http.StatusUnprocessableEntity, errs.Response())
 		return
 	}
 
-	password_hash, err := hashPassword(newUser.Password)
+	password_hash, err := auth.passwords.Hash(newUser.Password)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username and password are required"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
+		auth.log.Error(err.Error())
 		return
 	}
 
 	// Вставка пользователя в базу данных
 	userID, err := auth.repo.CreateUser(c.Request.Context(), newUser.Username, password_hash, newUser.Email, newUser.BirthDate, newUser.Gender)
 	if err != nil {
+		if errors.Is(err, repository.ErrUserExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "User with this username or email already exists"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
 		auth.log.Error(err.Error())
 		return
 	}
 
+	auth.events.Record(c, audit.EventRegistered, userID, gin.H{"method": "password"})
+
+	// Ошибка отправки не отменяет регистрацию: письмо можно запросить повторно
+	if err := auth.sendVerificationEmail(c.Request.Context(), userID, newUser.Email); err != nil {
+		auth.log.Error(err.Error())
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "User registered successfully",
+		"message": "User registered successfully, check your email to verify the account",
 		"user_id": userID,
 	})
 }
@@ -75,50 +172,111 @@ func (auth *AuthService) Login(c *gin.Context) {
 		return
 	}
 
-	userID, password_hash, err := auth.repo.FindUserByUserEmail(c.Request.Context(), loginData.Email)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username o password"})
+	// Проверяем блокировку до поиска пользователя и проверки пароля
+	if !auth.checkLoginAllowed(c, loginData.Email) {
 		return
 	}
-	if password_hash == "" {
 
-	}
-	/*if err := compareHashAndPassword(loginData.Password, password_hash); !err {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+	user, err := auth.repo.FindUserByUserEmail(c.Request.Context(), loginData.Email)
+	if err != nil {
+		// Проверяем фиктивный хэш, чтобы по времени ответа нельзя было узнать, есть ли такой email
+		auth.passwords.VerifyDummy(loginData.Password)
+		auth.loginFailed(c, 0, loginData.Email, "invalid_credentials")
 		return
 	}
-	*/
-	token, err := generateJWT(userID)
+
+	// У учетных записей, созданных через OIDC, пароля нет: Verify вернет false
+	match, err := auth.passwords.Verify(loginData.Password, user.PasswordHash)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		auth.log.Error(err.Error())
+	}
+	if !match {
+		auth.loginFailed(c, user.ID, loginData.Email, "invalid_credentials")
 		return
 	}
-	auth.log.Debug(token)
-	c.JSON(http.StatusOK, gin.H{
-		"message": "User logged in successfully",
-		"token":   token,
-	})
+
+	auth.rehashPassword(c.Request.Context(), user, loginData.Password)
+
+	if err := auth.limiter.Succeed(c.Request.Context(), loginData.Email); err != nil {
+		auth.log.Error(err.Error())
+	}
+
+	auth.completeLogin(c, user, "password")
 }
 
-func generateJWT(userID int) (string, error) {
+// completeLogin завершает вход пользователя, личность которого уже подтверждена
+// (паролем или внешним провайдером): при включенной 2FA выдает промежуточный токен,
+// иначе - пару токенов. method - способ входа для журнала безопасности.
+func (auth *AuthService) completeLogin(c *gin.Context, user *models.User, method string) {
+	// Удаленную учетную запись можно только восстановить через POST /account/restore
+	if user.DeletedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":      "Account is scheduled for deletion",
+			"deleted_at": user.DeletedAt,
+		})
+		return
+	}
 
-	var jwtSecret = []byte("your_secret_key")
+	if user.EmailVerifiedAt == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Email is not verified"})
+		return
+	}
+
+	// При включенной 2FA вместо токенов выдаем промежуточный токен для POST /login/2fa
+	if user.TOTPEnabled {
+		challenge, err := auth.generateMFAChallenge(user.ID, user.Email)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			auth.log.Error(err.Error())
+			return
+		}
 
-	claims := jwt.MapClaims{
-		"user_id": userID,                                // Полезные данные (payload)
-		"exp":     time.Now().Add(time.Hour * 24).Unix(), // Срок действия токена (24 часа)
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "Two-factor authentication required",
+			"mfa_required": true,
+			"mfa_token":    challenge,
+			"expires_in":   int(auth.cfg.MFAChallengeTTL.Seconds()),
+		})
+		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	auth.respondWithTokens(c, user.ID, method)
 }
 
-func hashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
-}
+// generateJWT выпускает access-токен сессии sessionID и возвращает его вместе с jti.
+// Роли читаются из базы, поэтому их изменение применяется при следующем обновлении токена.
+func (auth *AuthService) generateJWT(ctx context.Context, userID int, sessionID string) (string, string, error) {
+	now := time.Now()
+
+	jti, err := randomString(16)
+	if err != nil {
+		return "", "", err
+	}
+
+	roles, err := auth.repo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	claims := &middleware.Claims{
+		UserID:    userID, // Полезные данные (payload)
+		Roles:     roles,
+		Scopes:    rbac.ScopesForRoles(roles),
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.Itoa(userID),
+			Issuer:    auth.keys.Issuer(),
+			Audience:  jwt.ClaimStrings{auth.keys.Audience()},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(auth.tokenTTL)), // Срок действия токена
+		},
+	}
+
+	token, err := auth.keys.Sign(claims)
+	if err != nil {
+		return "", "", err
+	}
 
-func compareHashAndPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	return token, jti, nil
 }
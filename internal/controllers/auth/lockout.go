@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"math"
+	"net/http"
+	"passion-pals-backend/internal/utils/audit"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkLoginAllowed отвечает 429 с Retry-After, если вход для email или IP клиента заблокирован
+func (auth *AuthService) checkLoginAllowed(c *gin.Context, email string) bool {
+	retryAfter, err := auth.limiter.Check(c.Request.Context(), email, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		auth.log.Error(err.Error())
+		return false
+	}
+
+	if retryAfter <= 0 {
+		return true
+	}
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed login attempts, try again later",
+		"retry_after": seconds,
+	})
+
+	return false
+}
+
+// checkEmailAllowed учитывает запрос письма вида kind на email и отвечает 429 с Retry-After,
+// если лимит писем на адрес или на IP клиента исчерпан. failMessage - ответ при ошибке базы.
+func (auth *AuthService) checkEmailAllowed(c *gin.Context, kind, email, failMessage string) bool {
+	retryAfter, err := auth.limiter.Email(c.Request.Context(), kind, email, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failMessage})
+		auth.log.Error(err.Error())
+		return false
+	}
+
+	if retryAfter <= 0 {
+		return true
+	}
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many email requests, try again later",
+		"retry_after": seconds,
+	})
+
+	return false
+}
+
+// attemptFailed учитывает неверный пароль или код 2FA вне входа (смена пароля, настройка 2FA)
+func (auth *AuthService) attemptFailed(c *gin.Context, email string) {
+	if err := auth.limiter.Fail(c.Request.Context(), email, c.ClientIP()); err != nil {
+		auth.log.Error(err.Error())
+	}
+}
+
+// loginFailed учитывает неудачную попытку, записывает ее в журнал и отвечает 401.
+// userID == 0, если пользователь с таким email не найден: тогда в журнал попадает
+// хэш адреса, сам адрес в журнале не хранится.
+func (auth *AuthService) loginFailed(c *gin.Context, userID int, email, reason string) {
+	details := gin.H{"reason": reason}
+	if userID == 0 {
+		details["email_hash"] = audit.EmailHash(email)
+	}
+	auth.events.Record(c, audit.EventLoginFailed, userID, details)
+
+	auth.attemptFailed(c, email)
+
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/audit"
+	"passion-pals-backend/internal/utils/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logout отзывает текущий access-токен и, если передан, refresh-токен этого устройства
+func (auth *AuthService) Logout(c *gin.Context) {
+	var logoutData struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	// Тело запроса необязательно
+	_ = c.ShouldBindJSON(&logoutData)
+
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok || userClaims.ExpiresAt == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid claims format"})
+		return
+	}
+
+	userID := userClaims.UserID
+	sessionID := userClaims.SessionID
+
+	if err := auth.revoked.RevokeToken(c.Request.Context(), userClaims.ID, userID, userClaims.ExpiresAt.Time); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	// Завершаем сессию текущего устройства вместе с ее refresh-токенами
+	if _, err := auth.repo.RevokeSession(c.Request.Context(), userID, sessionID); err != nil &&
+		!errors.Is(err, repository.ErrSessionNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		auth.log.Error(err.Error())
+		return
+	}
+	auth.sessions.Forget(sessionID)
+
+	if logoutData.RefreshToken != "" {
+		err := auth.repo.RevokeRefreshTokenFamily(c.Request.Context(), userID, hashToken(logoutData.RefreshToken))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			auth.log.Error(err.Error())
+			return
+		}
+	}
+
+	auth.events.Record(c, audit.EventTokensRevoked, userID, gin.H{"reason": "logout", "session_id": sessionID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "User logged out successfully"})
+}
+
+// LogoutAll завершает все сессии пользователя на всех устройствах
+func (auth *AuthService) LogoutAll(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	if err := auth.revokeAllSessions(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	auth.events.Record(c, audit.EventTokensRevoked, userID, gin.H{"reason": "logout_all"})
+
+	c.JSON(http.StatusOK, gin.H{"message": "User logged out from all devices"})
+}
+
+// revokeAllSessions отзывает все access- и refresh-токены пользователя
+func (auth *AuthService) revokeAllSessions(ctx context.Context, userID int) error {
+	if err := auth.repo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := auth.repo.RevokeUserSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	return auth.revoked.RevokeUser(ctx, userID, auth.tokenTTL)
+}
+
+func userIDFromContext(c *gin.Context) (int, bool) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		return 0, false
+	}
+
+	return userClaims.UserID, true
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/mailer"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// magicLinkAudience аудитория токенов ссылок для входа без пароля
+	magicLinkAudience = "magic-link"
+
+	// magicLinkCookie хранит nonce, привязывающий ссылку к браузеру, который ее запросил.
+	// Новый запрос перезаписывает cookie, поэтому в браузере действует только последняя ссылка.
+	magicLinkCookie = "magic_link_nonce"
+)
+
+// RequestMagicLink отправляет на email одноразовую ссылку для входа без пароля.
+// Ответ не зависит от того, существует ли пользователь с таким email.
+func (auth *AuthService) RequestMagicLink(c *gin.Context) {
+	var linkData struct {
+		Email string `json:"email"`
+	}
+
+	if err := c.ShouldBindJSON(&linkData); err != nil || strings.TrimSpace(linkData.Email) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	// Ограничение действует для любого адреса, чтобы по ответу нельзя было узнать, зарегистрирован ли он
+	retryAfter, err := auth.limiter.MagicLink(c.Request.Context(), linkData.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send login link"})
+		auth.log.Error(err.Error())
+		return
+	}
+	if retryAfter > 0 {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many login link requests, try again later",
+			"retry_after": seconds,
+		})
+		return
+	}
+
+	nonce, err := randomString(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send login link"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	user, err := auth.repo.FindUserByUserEmail(c.Request.Context(), linkData.Email)
+	if err == nil && user.DeletedAt == nil {
+		if err := auth.sendMagicLink(c.Request.Context(), user.ID, user.Email, hashToken(nonce)); err != nil {
+			auth.log.Error(err.Error())
+		}
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(magicLinkCookie, nonce, int(auth.cfg.MagicLinkTTL.Seconds()), "/login/magic", "", c.Request.TLS != nil, true)
+	c.JSON(http.StatusOK, gin.H{"message": "If the account exists, a login link has been sent"})
+}
+
+// VerifyMagicLink выполняет вход по ссылке из письма и выдает те же токены, что и Login.
+// Токен принимается как из query (?token=, переход по ссылке), так и из JSON-тела.
+func (auth *AuthService) VerifyMagicLink(c *gin.Context) {
+	token := c.Query("token")
+
+	if c.Request.Method == http.MethodPost {
+		var verifyData struct {
+			Token string `json:"token"`
+		}
+
+		if err := c.ShouldBindJSON(&verifyData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+		token = verifyData.Token
+	}
+
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login token is required"})
+		return
+	}
+
+	nonce, err := c.Cookie(magicLinkCookie)
+	if err != nil || nonce == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login link must be opened in the browser that requested it"})
+		return
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := auth.keys.ParseFor(token, claims, magicLinkAudience); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login link"})
+		return
+	}
+
+	jti, _ := claims["jti"].(string)
+	userIDFloat, _ := claims["user_id"].(float64)
+
+	err = auth.repo.UseMagicLinkToken(c.Request.Context(), jti, int(userIDFloat), hashToken(nonce))
+	if err != nil {
+		if errors.Is(err, repository.ErrMagicLinkInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login link"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	// Ссылка погашена, nonce больше не нужен
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(magicLinkCookie, "", -1, "/login/magic", "", c.Request.TLS != nil, true)
+
+	user, err := auth.repo.FindUserByID(c.Request.Context(), int(userIDFloat))
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login link"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	// Владение почтой подтверждено, но вторым фактором ссылка не является: 2FA проверяется как обычно
+	auth.completeLogin(c, user, "magic_link")
+}
+
+// sendMagicLink выпускает одноразовый подписанный токен, привязанный к nonce браузера, и отправляет ссылку на email
+func (auth *AuthService) sendMagicLink(ctx context.Context, userID int, email, nonceHash string) error {
+	now := time.Now()
+	expiresAt := now.Add(auth.cfg.MagicLinkTTL)
+
+	jti, err := randomString(16)
+	if err != nil {
+		return fmt.Errorf("failed to generate login token: %w", err)
+	}
+
+	token, err := auth.keys.Sign(jwt.MapClaims{
+		"user_id": userID,
+		"jti":     jti,
+		"sub":     strconv.Itoa(userID),
+		"iss":     auth.keys.Issuer(),
+		"aud":     magicLinkAudience,
+		"iat":     now.Unix(),
+		"exp":     expiresAt.Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sign login token: %w", err)
+	}
+
+	if err := auth.repo.CreateMagicLinkToken(ctx, jti, userID, nonceHash, expiresAt); err != nil {
+		return err
+	}
+
+	link := auth.cfg.MagicLinkURL + "?token=" + url.QueryEscape(token)
+
+	return auth.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Вход в Passion Pals",
+		Body: "Здравствуйте!\r\n\r\n" +
+			"Чтобы войти в Passion Pals, перейдите по ссылке:\r\n" +
+			link + "\r\n\r\n" +
+			"Ссылка одноразовая и действует " + strconv.Itoa(int(auth.cfg.MagicLinkTTL.Minutes())) + " минут. " +
+			"Откройте ее в том же браузере, в котором запрашивали вход.\r\n" +
+			"Если вы не запрашивали вход, просто проигнорируйте это письмо.\r\n",
+	})
+}
@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	models "passion-pals-backend/internal/models"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/audit"
+	"passion-pals-backend/internal/utils/oidc"
+	"passion-pals-backend/internal/utils/validation"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oidcStateCookie привязывает попытку входа к браузеру, который ее начал
+	oidcStateCookie = "oidc_state"
+
+	// oidcRequestTTL время на прохождение входа у провайдера
+	oidcRequestTTL = 10 * time.Minute
+)
+
+// OIDCLogin перенаправляет пользователя на страницу входа провайдера
+// (authorization code flow с PKCE, state и nonce)
+func (auth *AuthService) OIDCLogin(c *gin.Context) {
+	provider, ok := auth.providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	var req models.OIDCAuthRequest
+	var err error
+
+	for _, value := range []*string{&req.State, &req.Nonce, &req.CodeVerifier} {
+		if *value, err = oidc.RandomString(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+			auth.log.Error(err.Error())
+			return
+		}
+	}
+
+	req.Provider = provider.Name()
+	req.ExpiresAt = time.Now().Add(oidcRequestTTL)
+
+	redirectURL, err := provider.AuthCodeURL(c.Request.Context(), req.State, req.Nonce, req.CodeVerifier)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider is unavailable"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	if err := auth.repo.CreateOIDCAuthRequest(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, req.State, int(oidcRequestTTL.Seconds()), "/oauth", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// OIDCCallback принимает код авторизации от провайдера, проверяет ID-токен,
+// находит или создает пользователя и выдает те же токены, что и Login
+func (auth *AuthService) OIDCCallback(c *gin.Context) {
+	provider, ok := auth.providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	if providerError := c.Query("error"); providerError != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login was rejected by the identity provider", "details": providerError})
+		return
+	}
+
+	state := c.Query("state")
+	code := c.Query("code")
+
+	cookieState, err := c.Cookie(oidcStateCookie)
+	if err != nil || state == "" || code == "" || cookieState != state {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid login state"})
+		return
+	}
+
+	// Cookie больше не нужна
+	c.SetCookie(oidcStateCookie, "", -1, "/oauth", "", c.Request.TLS != nil, true)
+
+	req, err := auth.repo.ConsumeOIDCAuthRequest(c.Request.Context(), state, provider.Name())
+	if err != nil {
+		if errors.Is(err, repository.ErrAuthRequestNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid login state"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	claims, err := provider.Exchange(c.Request.Context(), code, req.CodeVerifier, req.Nonce)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to verify identity provider response"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	identity := &models.ExternalIdentity{
+		Provider:      provider.Name(),
+		Subject:       claims.Subject,
+		Email:         validation.NormalizeEmail(claims.Email),
+		EmailVerified: claims.EmailVerified,
+		Username:      usernameFromClaims(claims),
+	}
+
+	userID, err := auth.resolveIdentity(c, identity)
+	if err != nil {
+		return
+	}
+
+	user, err := auth.repo.FindUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	auth.completeLogin(c, user, "oidc:"+provider.Name())
+}
+
+// resolveIdentity находит пользователя по внешней учетной записи, привязывает ее
+// к существующему пользователю с тем же подтвержденным email или создает нового.
+// При ошибке ответ уже отправлен клиенту.
+func (auth *AuthService) resolveIdentity(c *gin.Context, identity *models.ExternalIdentity) (int, error) {
+	ctx := c.Request.Context()
+
+	userID, err := auth.repo.FindUserIDByIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return userID, nil
+	}
+	if !errors.Is(err, repository.ErrIdentityNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		auth.log.Error(err.Error())
+		return 0, err
+	}
+
+	if identity.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Identity provider did not return an email"})
+		return 0, errors.New("identity has no email")
+	}
+
+	existing, err := auth.repo.FindUserByUserEmail(ctx, identity.Email)
+	if err == nil {
+		// Привязываем только подтвержденный провайдером email, иначе
+		// чужой аккаунт можно было бы захватить, указав его адрес у провайдера.
+		// Учетная запись тоже должна быть подтверждена: неподтвержденную мог заранее
+		// завести кто угодно со своим паролем, и привязка отдала бы ему вход владельца адреса.
+		if !identity.EmailVerified || existing.EmailVerifiedAt == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists"})
+			return 0, errors.New("email matches an existing account that cannot be linked")
+		}
+
+		if err := auth.repo.LinkIdentity(ctx, existing.ID, identity); err != nil {
+			if errors.Is(err, repository.ErrEmailNotVerified) {
+				c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists"})
+				return 0, err
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+			auth.log.Error(err.Error())
+			return 0, err
+		}
+
+		return existing.ID, nil
+	}
+
+	userID, err = auth.repo.CreateUserWithIdentity(ctx, identity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		auth.log.Error(err.Error())
+		return 0, err
+	}
+
+	auth.events.Record(c, audit.EventRegistered, userID, gin.H{"method": "oidc:" + identity.Provider})
+
+	// Провайдер не подтвердил email: войти можно будет после подтверждения по ссылке из письма
+	if !identity.EmailVerified {
+		if err := auth.sendVerificationEmail(ctx, userID, identity.Email); err != nil {
+			auth.log.Error(err.Error())
+		}
+	}
+
+	return userID, nil
+}
+
+// usernameFromClaims подбирает имя пользователя и добавляет случайный суффикс,
+// чтобы не конфликтовать с уже существующими именами
+func usernameFromClaims(claims *oidc.IDTokenClaims) string {
+	base := claims.PreferredUsername
+	if base == "" {
+		base = claims.Name
+	}
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	suffix, err := randomString(3)
+	if err != nil {
+		return base
+	}
+
+	return base + "_" + strings.ToLower(suffix)
+}
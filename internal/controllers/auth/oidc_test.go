@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"passion-pals-backend/internal/config"
+	"passion-pals-backend/internal/utils/oidc"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Проверки state выполняются до обращения к базе и к провайдеру
+func TestOIDCCallbackRejectsInvalidState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	providers, err := oidc.NewProviders(config.OIDCConfig{
+		RedirectBaseURL: "http://app.test",
+		Providers: []config.OIDCProviderConfig{{
+			Name:     "mock",
+			Issuer:   "http://idp.test",
+			ClientID: "passion-pals",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := &AuthService{providers: providers}
+	router := gin.New()
+	router.GET("/oauth/:provider/callback", auth.OIDCCallback)
+
+	tests := []struct {
+		name   string
+		target string
+		cookie string
+		want   int
+	}{
+		{"unknown provider", "/oauth/other/callback?code=c&state=s", "s", http.StatusNotFound},
+		{"no cookie", "/oauth/mock/callback?code=c&state=s", "", http.StatusBadRequest},
+		{"state mismatch", "/oauth/mock/callback?code=c&state=s", "other", http.StatusBadRequest},
+		{"no state", "/oauth/mock/callback?code=c", "s", http.StatusBadRequest},
+		{"no code", "/oauth/mock/callback?state=s", "s", http.StatusBadRequest},
+		{"provider error", "/oauth/mock/callback?error=access_denied&state=s", "s", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: tt.cookie})
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
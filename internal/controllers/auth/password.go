@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	models "passion-pals-backend/internal/models"
+	"passion-pals-backend/internal/utils/audit"
+	"passion-pals-backend/internal/utils/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChangePassword меняет пароль текущего пользователя по текущему паролю.
+// Все сессии завершаются, а этому устройству выдается новая пара токенов.
+func (auth *AuthService) ChangePassword(c *gin.Context) {
+	var passwordData struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+
+	if err := c.ShouldBindJSON(&passwordData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	user, err := auth.repo.FindUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	// Подбор текущего пароля ограничивается так же, как подбор при входе
+	if !auth.checkLoginAllowed(c, user.Email) {
+		return
+	}
+
+	match, err := auth.passwords.Verify(passwordData.CurrentPassword, user.PasswordHash)
+	if err != nil {
+		auth.log.Error(err.Error())
+	}
+	if !match {
+		auth.attemptFailed(c, user.Email)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+
+	var errs validation.Errors
+	auth.policy.Password(&errs, "new_password", passwordData.NewPassword)
+	if errs.Empty() && passwordData.NewPassword == passwordData.CurrentPassword {
+		errs.Add("new_password", validation.CodeInvalid, "New password must differ from the current one")
+	}
+	if !errs.Empty() {
+		c.JSON(http.StatusUnprocessableEntity, errs.Response())
+		return
+	}
+
+	passwordHash, err := auth.passwords.Hash(passwordData.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	updated, err := auth.repo.UpdatePassword(c.Request.Context(), userID, user.PasswordHash, passwordHash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		auth.log.Error(err.Error())
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusConflict, gin.H{"error": "Password was changed by another request"})
+		return
+	}
+
+	if err := auth.limiter.Succeed(c.Request.Context(), user.Email); err != nil {
+		auth.log.Error(err.Error())
+	}
+
+	auth.events.Record(c, audit.EventPasswordChanged, userID, nil)
+
+	if err := auth.revokeAllSessions(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password changed, but failed to revoke sessions"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	tokens, err := auth.issueTokens(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Password changed successfully",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int(auth.tokenTTL.Seconds()),
+	})
+}
+
+// rehashPassword после успешного входа перехэширует пароль, если хэш сделан
+// другим алгоритмом или с устаревшими параметрами. Ошибки не мешают входу.
+func (auth *AuthService) rehashPassword(ctx context.Context, user *models.User, plain string) {
+	if !auth.passwords.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	passwordHash, err := auth.passwords.Hash(plain)
+	if err != nil {
+		auth.log.Error("failed to rehash password", slog.String("error", err.Error()))
+		return
+	}
+
+	if _, err := auth.repo.UpdatePassword(ctx, user.ID, user.PasswordHash, passwordHash); err != nil {
+		auth.log.Error("failed to rehash password", slog.String("error", err.Error()))
+		return
+	}
+
+	user.PasswordHash = passwordHash
+}
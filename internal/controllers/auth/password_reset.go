@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/audit"
+	"passion-pals-backend/internal/utils/mailer"
+	"passion-pals-backend/internal/utils/validation"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPendingResetEmails сколько писем сброса пароля может отправляться в фоне одновременно
+const maxPendingResetEmails = 16
+
+// ForgotPassword отправляет ссылку для сброса пароля.
+// Ответ одинаковый независимо от того, зарегистрирован ли email.
+func (auth *AuthService) ForgotPassword(c *gin.Context) {
+	var forgotData struct {
+		Email string `json:"email"`
+	}
+
+	if err := c.ShouldBindJSON(&forgotData); err != nil || strings.TrimSpace(forgotData.Email) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	// Ограничение действует для любого адреса, чтобы по ответу нельзя было узнать, зарегистрирован ли он
+	if !auth.checkEmailAllowed(c, "reset", forgotData.Email, "Failed to send password reset link") {
+		return
+	}
+
+	// Письмо отправляется в фоне, чтобы время ответа тоже не выдавало наличие пользователя.
+	// Число фоновых отправок ограничено; отказ тоже не зависит от того, есть ли пользователь.
+	select {
+	case auth.resetMail <- struct{}{}:
+	default:
+		c.Header("Retry-After", "60")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Too many pending requests, try again later"})
+		return
+	}
+
+	go func(email string) {
+		defer func() { <-auth.resetMail }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		if err := auth.sendPasswordReset(ctx, email); err != nil {
+			auth.log.Error(err.Error())
+		}
+	}(forgotData.Email)
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the account exists, a password reset link has been sent"})
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма и завершает все сессии
+func (auth *AuthService) ResetPassword(c *gin.Context) {
+	var resetData struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	if err := c.ShouldBindJSON(&resetData); err != nil || resetData.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	var errs validation.Errors
+	auth.policy.Password(&errs, "password", resetData.Password)
+	if !errs.Empty() {
+		c.JSON(http.StatusUnprocessableEntity, errs.Response())
+		return
+	}
+
+	passwordHash, err := auth.passwords.Hash(resetData.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	userID, err := auth.repo.ResetPassword(c.Request.Context(), hashToken(resetData.Token), passwordHash)
+	if err != nil {
+		if errors.Is(err, repository.ErrResetTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	auth.events.Record(c, audit.EventPasswordReset, userID, nil)
+
+	if err := auth.revokeAllSessions(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password changed, but failed to revoke sessions"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset successfully"})
+}
+
+// sendPasswordReset выпускает токен сброса и отправляет ссылку, если пользователь существует
+func (auth *AuthService) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := auth.repo.FindUserByUserEmail(ctx, email)
+	if err != nil {
+		// Пользователь не найден - молча ничего не отправляем
+		return nil
+	}
+
+	token, tokenHash, err := newOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	if err := auth.repo.CreatePasswordResetToken(ctx, user.ID, tokenHash, time.Now().Add(auth.cfg.PasswordResetTTL)); err != nil {
+		return err
+	}
+
+	link := auth.cfg.ResetPasswordURL + "?token=" + url.QueryEscape(token)
+
+	return auth.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля",
+		Body: "Здравствуйте!\r\n\r\n" +
+			"Чтобы задать новый пароль, перейдите по ссылке:\r\n" +
+			link + "\r\n\r\n" +
+			fmt.Sprintf("Ссылка действует %d мин. и может быть использована один раз.\r\n", int(auth.cfg.PasswordResetTTL.Minutes())) +
+			"Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.\r\n",
+	})
+}
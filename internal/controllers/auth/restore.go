@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RestoreAccount отменяет удаление учетной записи до истечения срока восстановления.
+// Вход в удаленную учетную запись заблокирован, поэтому личность подтверждается
+// паролем и, если включена, двухфакторной аутентификацией.
+func (auth *AuthService) RestoreAccount(c *gin.Context) {
+	var restoreData struct {
+		Email        string `json:"email"`
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	if err := c.ShouldBindJSON(&restoreData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if !auth.checkLoginAllowed(c, restoreData.Email) {
+		return
+	}
+
+	user, err := auth.repo.FindUserByUserEmail(c.Request.Context(), restoreData.Email)
+	if err != nil {
+		auth.passwords.VerifyDummy(restoreData.Password)
+		auth.loginFailed(c, 0, restoreData.Email, "invalid_credentials")
+		return
+	}
+
+	match, err := auth.passwords.Verify(restoreData.Password, user.PasswordHash)
+	if err != nil {
+		auth.log.Error(err.Error())
+	}
+	if !match {
+		auth.loginFailed(c, user.ID, restoreData.Email, "invalid_credentials")
+		return
+	}
+
+	if user.DeletedAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account is not scheduled for deletion"})
+		return
+	}
+
+	if user.TOTPEnabled {
+		if restoreData.Code == "" && restoreData.RecoveryCode == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":        "Two-factor authentication code is required",
+				"mfa_required": true,
+			})
+			return
+		}
+
+		state, err := auth.repo.GetTOTPState(c.Request.Context(), user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore account"})
+			auth.log.Error(err.Error())
+			return
+		}
+
+		valid, err := auth.verifySecondFactor(c.Request.Context(), user.ID, state.Secret, restoreData.Code, restoreData.RecoveryCode)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore account"})
+			auth.log.Error(err.Error())
+			return
+		}
+		if !valid {
+			auth.loginFailed(c, user.ID, restoreData.Email, "invalid_second_factor")
+			return
+		}
+	}
+
+	if err := auth.repo.RestoreUser(c.Request.Context(), user.ID); err != nil {
+		if errors.Is(err, repository.ErrUserNotDeleted) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Account is not scheduled for deletion"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore account"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	auth.events.Record(c, audit.EventAccountRestored, user.ID, nil)
+
+	if err := auth.limiter.Succeed(c.Request.Context(), restoreData.Email); err != nil {
+		auth.log.Error(err.Error())
+	}
+
+	if user.EmailVerifiedAt == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Account restored, verify your email to log in"})
+		return
+	}
+
+	// Второй фактор уже проверен выше, поэтому сразу выдаем токены
+	auth.respondWithTokens(c, user.ID, "restore")
+}
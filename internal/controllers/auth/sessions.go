@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	models "passion-pals-backend/internal/models"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/audit"
+	"passion-pals-backend/internal/utils/middleware"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxUserAgentLength ограничение длины сохраняемого User-Agent
+const maxUserAgentLength = 512
+
+// sessionView сессия в ответе API с отметкой текущего устройства
+type sessionView struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+// ListSessions возвращает устройства, на которых выполнен вход
+func (auth *AuthService) ListSessions(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid claims format"})
+		return
+	}
+
+	sessions, err := auth.repo.GetUserSessions(c.Request.Context(), userClaims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sessions"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	views := make([]sessionView, 0, len(sessions))
+	for _, session := range sessions {
+		views = append(views, sessionView{
+			Session: session,
+			Current: session.ID == userClaims.SessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": views})
+}
+
+// RevokeSession завершает сессию на одном устройстве
+func (auth *AuthService) RevokeSession(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	sessionID := c.Param("id")
+
+	jti, err := auth.repo.RevokeSession(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		auth.log.Error(err.Error())
+		return
+	}
+	auth.sessions.Forget(sessionID)
+
+	// Текущий access-токен сессии отзываем сразу, не дожидаясь следующей проверки сессии
+	if err := auth.revoked.RevokeToken(c.Request.Context(), jti, userID, time.Now().Add(auth.tokenTTL)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	auth.events.Record(c, audit.EventTokensRevoked, userID, gin.H{"reason": "session_revoked", "session_id": sessionID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// createSession сохраняет новую сессию с данными устройства из запроса
+func (auth *AuthService) createSession(c *gin.Context, userID int, sessionID, jti string) error {
+	now := time.Now()
+
+	return auth.repo.CreateSession(c.Request.Context(), models.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		CurrentJTI: jti,
+		UserAgent:  userAgent(c),
+		IP:         c.ClientIP(),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(auth.refreshTokenTTL),
+	})
+}
+
+func userAgent(c *gin.Context) string {
+	ua := c.Request.UserAgent()
+	if len(ua) > maxUserAgentLength {
+		ua = ua[:maxUserAgentLength]
+	}
+
+	return ua
+}
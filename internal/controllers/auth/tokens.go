@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/audit"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenPair пара токенов, выдаваемая при входе и при обновлении
+type tokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов (ротация)
+func (auth *AuthService) Refresh(c *gin.Context) {
+	var refreshData struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := c.ShouldBindJSON(&refreshData); err != nil || refreshData.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	refreshToken, refreshHash, err := newOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	userID, familyID, err := auth.repo.RotateRefreshToken(
+		c.Request.Context(),
+		hashToken(refreshData.RefreshToken),
+		refreshHash,
+		time.Now().Add(auth.refreshTokenTTL),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRefreshTokenReused):
+			auth.log.Warn("refresh token reuse detected, family revoked",
+				"user_id", userID, "family_id", familyID)
+			auth.events.Record(c, audit.EventTokensRevoked, userID, gin.H{"reason": "refresh_reuse", "session_id": familyID})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		case errors.Is(err, repository.ErrRefreshTokenNotFound),
+			errors.Is(err, repository.ErrRefreshTokenRevoked):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		case errors.Is(err, repository.ErrRefreshTokenExpired):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has expired"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+			auth.log.Error(err.Error())
+		}
+		return
+	}
+
+	// Семейство refresh-токенов и есть сессия устройства
+	accessToken, jti, err := auth.generateJWT(c.Request.Context(), userID, familyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	err = auth.repo.UpdateSessionToken(c.Request.Context(), familyID, jti,
+		userAgent(c), c.ClientIP(), time.Now().Add(auth.refreshTokenTTL))
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		// Семейство выпущено до появления сессий: заводим сессию для него
+		err = auth.createSession(c, userID, familyID, jti)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(auth.tokenTTL.Seconds()),
+	})
+}
+
+// respondWithTokens выдает пару токенов нового семейства, отправляет их клиенту
+// и записывает успешный вход способом method в журнал безопасности
+func (auth *AuthService) respondWithTokens(c *gin.Context, userID int, method string) {
+	tokens, err := auth.issueTokens(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	auth.events.Record(c, audit.EventLoginSucceeded, userID, gin.H{"method": method})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "User logged in successfully",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int(auth.tokenTTL.Seconds()),
+	})
+}
+
+// issueTokens открывает новую сессию и выдает access-токен и refresh-токен нового семейства
+func (auth *AuthService) issueTokens(c *gin.Context, userID int) (*tokenPair, error) {
+	familyID, err := randomString(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token family: %w", err)
+	}
+
+	accessToken, jti, err := auth.generateJWT(c.Request.Context(), userID, familyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	refreshToken, refreshHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.createSession(c, userID, familyID, jti); err != nil {
+		return nil, err
+	}
+
+	err = auth.repo.CreateRefreshToken(c.Request.Context(), userID, familyID, refreshHash, time.Now().Add(auth.refreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// newOpaqueToken генерирует случайный непрозрачный токен и его хэш для хранения в БД
+func newOpaqueToken() (string, string, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return token, hashToken(token), nil
+}
+
+// hashToken возвращает SHA-256 хэш токена в hex
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,364 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"net/http"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/totp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// mfaAudience аудитория промежуточного токена, выдаваемого после проверки пароля
+	mfaAudience = "mfa"
+
+	// totpSkew допустимое расхождение часов клиента, в шагах по 30 секунд
+	totpSkew = 1
+
+	recoveryCodesCount = 10
+)
+
+// EnrollTOTP создает новый секрет и возвращает otpauth:// URI для приложения-аутентификатора.
+// 2FA включается только после подтверждения кодом в ConfirmTOTP.
+func (auth *AuthService) EnrollTOTP(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	user, err := auth.repo.FindUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll two-factor authentication"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll two-factor authentication"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	if err := auth.repo.SetPendingTOTPSecret(c.Request.Context(), userID, secret); err != nil {
+		if errors.Is(err, repository.ErrTOTPAlreadyEnabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll two-factor authentication"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": totp.URI(auth.cfg.TOTPIssuer, user.Email, secret),
+	})
+}
+
+// ConfirmTOTP включает 2FA после проверки первого кода и выдает коды восстановления.
+// Коды показываются только один раз, в базе хранятся их хэши.
+func (auth *AuthService) ConfirmTOTP(c *gin.Context) {
+	var confirmData struct {
+		Code string `json:"code"`
+	}
+
+	if err := c.ShouldBindJSON(&confirmData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	user, err := auth.repo.FindUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm two-factor authentication"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	// Подбор кода ограничивается так же, как подбор при входе
+	if !auth.checkLoginAllowed(c, user.Email) {
+		return
+	}
+
+	state, err := auth.repo.GetTOTPState(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm two-factor authentication"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	if state.EnabledAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if state.Secret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor enrollment has not been started"})
+		return
+	}
+
+	step, ok := totp.Validate(state.Secret, confirmData.Code, time.Now(), totpSkew)
+	if !ok {
+		auth.attemptFailed(c, user.Email)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm two-factor authentication"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	if err := auth.repo.EnableTOTP(c.Request.Context(), userID, step, hashes); err != nil {
+		if errors.Is(err, repository.ErrTOTPAlreadyEnabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm two-factor authentication"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	if err := auth.limiter.Succeed(c.Request.Context(), user.Email); err != nil {
+		auth.log.Error(err.Error())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTOTP выключает 2FA. Требует текущий пароль вместе с действующим кодом
+// либо код восстановления: украденной сессии и приложения-аутентификатора недостаточно.
+func (auth *AuthService) DisableTOTP(c *gin.Context) {
+	var disableData struct {
+		CurrentPassword string `json:"current_password"`
+		Code            string `json:"code"`
+		RecoveryCode    string `json:"recovery_code"`
+	}
+
+	if err := c.ShouldBindJSON(&disableData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	user, err := auth.repo.FindUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	if !auth.checkLoginAllowed(c, user.Email) {
+		return
+	}
+
+	state, err := auth.repo.GetTOTPState(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	if state.EnabledAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	// Код восстановления одноразовый и показывается только при включении 2FA,
+	// поэтому заменяет пароль; с кодом из приложения пароль обязателен
+	if disableData.RecoveryCode == "" {
+		match, err := auth.passwords.Verify(disableData.CurrentPassword, user.PasswordHash)
+		if err != nil {
+			auth.log.Error(err.Error())
+		}
+		if !match {
+			auth.attemptFailed(c, user.Email)
+			c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+			return
+		}
+	}
+
+	valid, err := auth.verifySecondFactor(c.Request.Context(), userID, state.Secret, disableData.Code, disableData.RecoveryCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		auth.log.Error(err.Error())
+		return
+	}
+	if !valid {
+		auth.attemptFailed(c, user.Email)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	if err := auth.repo.DisableTOTP(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	if err := auth.limiter.Succeed(c.Request.Context(), user.Email); err != nil {
+		auth.log.Error(err.Error())
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// Login2FA обменивает промежуточный токен из Login и код 2FA на пару токенов.
+// Промежуточный токен одноразовый: после любой попытки, в том числе с неверным кодом,
+// вход нужно начинать заново с пароля.
+func (auth *AuthService) Login2FA(c *gin.Context) {
+	var loginData struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	if err := c.ShouldBindJSON(&loginData); err != nil || loginData.MFAToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := auth.keys.ParseFor(loginData.MFAToken, claims, mfaAudience); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge token"})
+		return
+	}
+
+	userIDFloat, _ := claims["user_id"].(float64)
+	userID := int(userIDFloat)
+	email, _ := claims["email"].(string)
+
+	if !auth.checkLoginAllowed(c, email) {
+		return
+	}
+
+	jti, _ := claims["jti"].(string)
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || jti == "" || expiresAt == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge token"})
+		return
+	}
+
+	if err := auth.repo.UseMFAChallenge(c.Request.Context(), jti, userID, expiresAt.Time); err != nil {
+		if errors.Is(err, repository.ErrMFAChallengeUsed) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	state, err := auth.repo.GetTOTPState(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	if state.EnabledAt == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge token"})
+		return
+	}
+
+	valid, err := auth.verifySecondFactor(c.Request.Context(), userID, state.Secret, loginData.Code, loginData.RecoveryCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		auth.log.Error(err.Error())
+		return
+	}
+	if !valid {
+		auth.loginFailed(c, userID, email, "invalid_second_factor")
+		return
+	}
+
+	if err := auth.limiter.Succeed(c.Request.Context(), email); err != nil {
+		auth.log.Error(err.Error())
+	}
+
+	auth.respondWithTokens(c, userID, "totp")
+}
+
+// generateMFAChallenge выпускает короткоживущий токен, подтверждающий, что пароль уже проверен
+func (auth *AuthService) generateMFAChallenge(userID int, email string) (string, error) {
+	now := time.Now()
+
+	jti, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	return auth.keys.Sign(jwt.MapClaims{
+		"user_id": userID,
+		"email":   email,
+		"jti":     jti,
+		"sub":     strconv.Itoa(userID),
+		"iss":     auth.keys.Issuer(),
+		"aud":     mfaAudience,
+		"iat":     now.Unix(),
+		"exp":     now.Add(auth.cfg.MFAChallengeTTL).Unix(),
+	})
+}
+
+// verifySecondFactor проверяет TOTP-код (один раз на временной шаг) или погашает код восстановления
+func (auth *AuthService) verifySecondFactor(ctx context.Context, userID int, secret, code, recoveryCode string) (bool, error) {
+	if recoveryCode != "" {
+		return auth.repo.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(recoveryCode)))
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return false, nil
+	}
+
+	return auth.repo.UseTOTPStep(ctx, userID, step)
+}
+
+// generateRecoveryCodes возвращает коды вида "abcd-efgh" и их хэши
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		raw := strings.ToLower(encoding.EncodeToString(b))
+		codes = append(codes, raw[:4]+"-"+raw[4:])
+		hashes = append(hashes, hashToken(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/mailer"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// verifyEmailAudience аудитория токенов подтверждения email.
+// Отличается от аудитории access-токенов, поэтому такой токен не пройдет AuthMiddleware.
+const verifyEmailAudience = "verify-email"
+
+// VerifyEmail подтверждает email по одноразовому токену из письма.
+// Токен принимается как из query (?token=, переход по ссылке), так и из JSON-тела.
+func (auth *AuthService) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+
+	if c.Request.Method == http.MethodPost {
+		var verifyData struct {
+			Token string `json:"token"`
+		}
+
+		if err := c.ShouldBindJSON(&verifyData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+		token = verifyData.Token
+	}
+
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification token is required"})
+		return
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := auth.keys.ParseFor(token, claims, verifyEmailAudience); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	jti, _ := claims["jti"].(string)
+	userIDFloat, _ := claims["user_id"].(float64)
+
+	err := auth.repo.UseEmailVerificationToken(c.Request.Context(), jti, int(userIDFloat))
+	if err != nil {
+		if errors.Is(err, repository.ErrVerificationTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		auth.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerification повторно отправляет письмо с подтверждением.
+// Ответ не зависит от того, существует ли пользователь с таким email.
+func (auth *AuthService) ResendVerification(c *gin.Context) {
+	var resendData struct {
+		Email string `json:"email"`
+	}
+
+	if err := c.ShouldBindJSON(&resendData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	// Ограничение действует для любого адреса, чтобы по ответу нельзя было узнать, зарегистрирован ли он
+	if !auth.checkEmailAllowed(c, "verify", resendData.Email, "Failed to send verification email") {
+		return
+	}
+
+	user, err := auth.repo.FindUserByUserEmail(c.Request.Context(), resendData.Email)
+	if err == nil && user.EmailVerifiedAt == nil {
+		if err := auth.sendVerificationEmail(c.Request.Context(), user.ID, user.Email); err != nil {
+			auth.log.Error(err.Error())
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the account exists and is not verified, a verification email has been sent"})
+}
+
+// sendVerificationEmail выпускает одноразовый подписанный токен и отправляет ссылку на email
+func (auth *AuthService) sendVerificationEmail(ctx context.Context, userID int, email string) error {
+	now := time.Now()
+	expiresAt := now.Add(auth.cfg.EmailVerificationTTL)
+
+	jti, err := randomString(16)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	token, err := auth.keys.Sign(jwt.MapClaims{
+		"user_id": userID,
+		"jti":     jti,
+		"sub":     strconv.Itoa(userID),
+		"iss":     auth.keys.Issuer(),
+		"aud":     verifyEmailAudience,
+		"iat":     now.Unix(),
+		"exp":     expiresAt.Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sign verification token: %w", err)
+	}
+
+	if err := auth.repo.CreateEmailVerificationToken(ctx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+
+	link := auth.cfg.VerifyEmailURL + "?token=" + url.QueryEscape(token)
+
+	return auth.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Подтверждение email",
+		Body: "Здравствуйте!\r\n\r\n" +
+			"Чтобы подтвердить адрес электронной почты, перейдите по ссылке:\r\n" +
+			link + "\r\n\r\n" +
+			"Если вы не регистрировались в Passion Pals, просто проигнорируйте это письмо.\r\n",
+	})
+}
@@ -0,0 +1,121 @@
+package export
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	models "passion-pals-backend/internal/models"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/export"
+	"passion-pals-backend/internal/utils/middleware"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportService struct {
+	log      *slog.Logger
+	repo     *repository.Repository
+	exporter *export.Exporter
+}
+
+func New(log *slog.Logger, repo *repository.Repository, exporter *export.Exporter) *ExportService {
+	return &ExportService{
+		log:      log,
+		repo:     repo,
+		exporter: exporter,
+	}
+}
+
+// RequestExport ставит в очередь сборку архива с персональными данными.
+// Пока предыдущая выгрузка не завершена, возвращается она.
+func (service *ExportService) RequestExport(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	id, err := newExportID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request data export"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	dataExport, created, err := service.repo.CreateDataExport(c.Request.Context(), id, userClaims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request data export"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	if created {
+		service.exporter.Enqueue()
+	}
+
+	c.Header("Location", "/profile/export/"+dataExport.ID)
+	c.JSON(http.StatusAccepted, gin.H{"export": dataExport})
+}
+
+// GetExport возвращает статус выгрузки
+func (service *ExportService) GetExport(c *gin.Context) {
+	dataExport, ok := service.findExport(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"export": dataExport})
+}
+
+// DownloadExport отдает готовый архив
+func (service *ExportService) DownloadExport(c *gin.Context) {
+	dataExport, ok := service.findExport(c)
+	if !ok {
+		return
+	}
+
+	if dataExport.ExpiresAt != nil && time.Now().After(*dataExport.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"error": "Data export has expired"})
+		return
+	}
+
+	if dataExport.Status != models.ExportReady {
+		c.JSON(http.StatusConflict, gin.H{"error": "Data export is not ready", "status": dataExport.Status})
+		return
+	}
+
+	c.FileAttachment(dataExport.FilePath, "passion-pals-export-"+dataExport.CreatedAt.Format("2006-01-02")+".zip")
+}
+
+func (service *ExportService) findExport(c *gin.Context) (*models.DataExport, bool) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return nil, false
+	}
+
+	dataExport, err := service.repo.GetDataExport(c.Request.Context(), userClaims.UserID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrDataExportNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Data export not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get data export"})
+		service.log.Error(err.Error())
+		return nil, false
+	}
+
+	return dataExport, true
+}
+
+func newExportID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
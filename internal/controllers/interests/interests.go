@@ -0,0 +1,335 @@
+package interests
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	models "passion-pals-backend/internal/models"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/middleware"
+	"passion-pals-backend/internal/utils/validation"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxProfileInterests сколько интересов можно указать в анкете
+	maxProfileInterests = 20
+
+	maxNameLength    = 50
+	maxSynonymLength = 50
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// InterestsService каталог интересов и интересы анкеты текущего пользователя
+type InterestsService struct {
+	log  *slog.Logger
+	repo *repository.Repository
+}
+
+func New(log *slog.Logger, repo *repository.Repository) *InterestsService {
+	return &InterestsService{
+		log:  log,
+		repo: repo,
+	}
+}
+
+// GetCatalog возвращает каталог интересов по категориям. ?q= ищет по slug, названию и синонимам.
+func (service *InterestsService) GetCatalog(c *gin.Context) {
+	categories, err := service.repo.GetInterestCatalog(c.Request.Context(), c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get interests"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
+// GetProfileInterests возвращает интересы анкеты текущего пользователя
+func (service *InterestsService) GetProfileInterests(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	interests, err := service.repo.GetProfileInterests(c.Request.Context(), userClaims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get interests"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"interests": interests})
+}
+
+// SetProfileInterests заменяет интересы анкеты текущего пользователя. Интересы можно
+// указывать по slug, названию или синониму.
+func (service *InterestsService) SetProfileInterests(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	var interestsData struct {
+		Interests []string `json:"interests"`
+	}
+
+	if err := c.ShouldBindJSON(&interestsData); err != nil || interestsData.Interests == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	interestIDs, ok := service.resolve(c, "interests", interestsData.Interests)
+	if !ok {
+		return
+	}
+
+	if len(interestIDs) > maxProfileInterests {
+		var errs validation.Errors
+		errs.Add("interests", validation.CodeTooLong, fmt.Sprintf("At most %d interests are allowed", maxProfileInterests))
+		c.JSON(http.StatusUnprocessableEntity, errs.Response())
+		return
+	}
+
+	err := service.repo.SetProfileInterests(c.Request.Context(), userClaims.UserID, interestIDs)
+	if err != nil {
+		if errors.Is(err, repository.ErrProfileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+			return
+		}
+		if errors.Is(err, repository.ErrInterestNotFound) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Interest was removed from the catalog, try again"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update interests"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	service.GetProfileInterests(c)
+}
+
+// CreateCategory добавляет категорию в каталог
+func (service *InterestsService) CreateCategory(c *gin.Context) {
+	var category models.InterestCategory
+
+	if err := c.ShouldBindJSON(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	category.Slug = strings.TrimSpace(category.Slug)
+	category.Name = strings.TrimSpace(category.Name)
+	category.Interests = nil
+
+	var errs validation.Errors
+	validateSlug(&errs, category.Slug)
+	validateName(&errs, category.Name)
+	if !errs.Empty() {
+		c.JSON(http.StatusUnprocessableEntity, errs.Response())
+		return
+	}
+
+	if err := service.repo.CreateInterestCategory(c.Request.Context(), &category); err != nil {
+		if errors.Is(err, repository.ErrInterestCategoryExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Category with this slug already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// CreateInterest добавляет интерес в каталог
+func (service *InterestsService) CreateInterest(c *gin.Context) {
+	var interest models.Interest
+
+	if err := c.ShouldBindJSON(&interest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	interest.Slug = strings.TrimSpace(interest.Slug)
+	interest.Name = strings.TrimSpace(interest.Name)
+	interest.Synonyms = normalizeSynonyms(interest.Synonyms)
+
+	var errs validation.Errors
+	validateSlug(&errs, interest.Slug)
+	validateName(&errs, interest.Name)
+	validateSynonyms(&errs, interest.Synonyms)
+	if interest.CategoryID <= 0 {
+		errs.Add("category_id", validation.CodeRequired, "Category is required")
+	}
+	if !errs.Empty() {
+		c.JSON(http.StatusUnprocessableEntity, errs.Response())
+		return
+	}
+
+	if err := service.repo.CreateInterest(c.Request.Context(), &interest); err != nil {
+		service.catalogWriteFailed(c, err, "Failed to create interest")
+		return
+	}
+
+	c.JSON(http.StatusCreated, interest)
+}
+
+// UpdateInterest изменяет название, категорию или синонимы интереса. Slug не меняется.
+func (service *InterestsService) UpdateInterest(c *gin.Context) {
+	interestID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interest ID"})
+		return
+	}
+
+	var interestData struct {
+		Name       *string   `json:"name"`
+		CategoryID *int      `json:"category_id"`
+		Synonyms   *[]string `json:"synonyms"`
+	}
+
+	if err := c.ShouldBindJSON(&interestData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	var update models.InterestUpdate
+	var errs validation.Errors
+
+	if interestData.Name != nil {
+		name := strings.TrimSpace(*interestData.Name)
+		validateName(&errs, name)
+		update.Name = &name
+	}
+	if interestData.CategoryID != nil {
+		if *interestData.CategoryID <= 0 {
+			errs.Add("category_id", validation.CodeInvalid, "Category is not valid")
+		}
+		update.CategoryID = interestData.CategoryID
+	}
+	if interestData.Synonyms != nil {
+		update.Synonyms = normalizeSynonyms(*interestData.Synonyms)
+		validateSynonyms(&errs, update.Synonyms)
+	}
+
+	if !errs.Empty() {
+		c.JSON(http.StatusUnprocessableEntity, errs.Response())
+		return
+	}
+
+	interest, err := service.repo.UpdateInterest(c.Request.Context(), interestID, update)
+	if err != nil {
+		service.catalogWriteFailed(c, err, "Failed to update interest")
+		return
+	}
+
+	c.JSON(http.StatusOK, interest)
+}
+
+// DeleteInterest удаляет интерес из каталога и из всех анкет
+func (service *InterestsService) DeleteInterest(c *gin.Context) {
+	interestID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interest ID"})
+		return
+	}
+
+	if err := service.repo.DeleteInterest(c.Request.Context(), interestID); err != nil {
+		service.catalogWriteFailed(c, err, "Failed to delete interest")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Interest deleted successfully"})
+}
+
+// resolve сопоставляет значения с каталогом и возвращает id без повторов. Если какие-то
+// значения не найдены, отвечает 422 и возвращает false.
+func (service *InterestsService) resolve(c *gin.Context, field string, terms []string) ([]int, bool) {
+	interestIDs, unknown, err := service.repo.ResolveInterests(c.Request.Context(), terms)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve interests"})
+		service.log.Error(err.Error())
+		return nil, false
+	}
+
+	if len(unknown) > 0 {
+		var errs validation.Errors
+		errs.Add(field, validation.CodeNotAllow, "Unknown interests: "+strings.Join(unknown, ", "))
+		c.JSON(http.StatusUnprocessableEntity, errs.Response())
+		return nil, false
+	}
+
+	return interestIDs, true
+}
+
+// catalogWriteFailed отвечает на ошибку изменения каталога
+func (service *InterestsService) catalogWriteFailed(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrInterestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Interest not found"})
+	case errors.Is(err, repository.ErrInterestCategoryNotFound):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Category not found"})
+	case errors.Is(err, repository.ErrInterestExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Interest with this slug, name or synonym already exists"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+		service.log.Error(err.Error())
+	}
+}
+
+func validateSlug(errs *validation.Errors, slug string) {
+	if slug == "" {
+		errs.Add("slug", validation.CodeRequired, "Slug is required")
+		return
+	}
+
+	if len(slug) > maxNameLength || !slugPattern.MatchString(slug) {
+		errs.Add("slug", validation.CodeInvalid, "Slug may contain only lowercase latin letters, digits and single hyphens")
+	}
+}
+
+func validateName(errs *validation.Errors, name string) {
+	if name == "" {
+		errs.Add("name", validation.CodeRequired, "Name is required")
+		return
+	}
+
+	if utf8.RuneCountInString(name) > maxNameLength {
+		errs.Add("name", validation.CodeTooLong, fmt.Sprintf("Name must be at most %d characters long", maxNameLength))
+	}
+}
+
+func validateSynonyms(errs *validation.Errors, synonyms []string) {
+	for _, synonym := range synonyms {
+		if utf8.RuneCountInString(synonym) > maxSynonymLength {
+			errs.Add("synonyms", validation.CodeTooLong, fmt.Sprintf("Synonyms must be at most %d characters long", maxSynonymLength))
+			return
+		}
+	}
+}
+
+// normalizeSynonyms приводит синонимы к нижнему регистру и убирает пустые и повторы
+func normalizeSynonyms(synonyms []string) []string {
+	normalized := []string{}
+
+	for _, synonym := range synonyms {
+		synonym = strings.ToLower(strings.TrimSpace(synonym))
+		if synonym != "" && !slices.Contains(normalized, synonym) {
+			normalized = append(normalized, synonym)
+		}
+	}
+
+	return normalized
+}
@@ -6,11 +6,11 @@ import (
 	"log/slog"
 	"net/http"
 	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/middleware"
 
 	models "passion-pals-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type NotifyService struct {
@@ -27,27 +27,12 @@ func New(log *slog.Logger, repo *repository.Repository) *NotifyService {
 
 func (notify *NotifyService) GetNotifications(c *gin.Context) {
 	// Извлекаем user_id из контекста
-	claims, exists := c.Get("userClaims")
-	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
-		return
-	}
-
-	// Приводим к map[string]interface{} сначала
-	userClaims, ok := claims.(jwt.MapClaims)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid claims format"})
-		return
-	}
-
-	// Теперь можно безопасно извлекать данные
-	userIDFloat, ok := userClaims["user_id"].(float64)
-	userID := int(userIDFloat)
-
+	userClaims, ok := middleware.ClaimsFromContext(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
 		return
 	}
+	userID := userClaims.UserID
 
 	// Используем userID для получения профиля
 	notifications, err := notify.repo.GetNotifications(c.Request.Context(), userID)
@@ -0,0 +1,365 @@
+package photos
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"passion-pals-backend/internal/config"
+	models "passion-pals-backend/internal/models"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/images"
+	"passion-pals-backend/internal/utils/middleware"
+	"passion-pals-backend/internal/utils/storage"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead запас на заголовки multipart сверх размера самого файла
+const multipartOverhead = 1 << 20
+
+// PhotosService галерея фотографий анкеты
+type PhotosService struct {
+	log       *slog.Logger
+	repo      *repository.Repository
+	storage   storage.Storage
+	processor *images.Processor
+	cfg       config.PhotosConfig
+}
+
+func New(
+	log *slog.Logger,
+	repo *repository.Repository,
+	storage storage.Storage,
+	processor *images.Processor,
+	cfg config.PhotosConfig,
+) *PhotosService {
+	return &PhotosService{
+		log:       log,
+		repo:      repo,
+		storage:   storage,
+		processor: processor,
+		cfg:       cfg,
+	}
+}
+
+// GetPhotos возвращает галерею анкеты текущего пользователя
+func (service *PhotosService) GetPhotos(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	photos, err := service.repo.GetUserPhotos(c.Request.Context(), userClaims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get photos"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"photos": photos})
+}
+
+// GetProfilePhotos возвращает галерею анкеты по id. Если владелец скрыл фотографии,
+// другим пользователям возвращается пустая галерея.
+func (service *PhotosService) GetProfilePhotos(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	profileID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || profileID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid profile ID"})
+		return
+	}
+
+	profile, err := service.repo.GetProfileByID(c.Request.Context(), profileID)
+	if err != nil {
+		if errors.Is(err, repository.ErrProfileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get photos"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	if profile.UserID != userClaims.UserID && profile.IsHidden("photos") {
+		c.JSON(http.StatusOK, gin.H{"photos": []*models.ProfilePhoto{}})
+		return
+	}
+
+	photos, err := service.repo.GetProfilePhotos(c.Request.Context(), profileID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get photos"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"photos": photos})
+}
+
+// UploadPhoto добавляет фотографию из поля photo (multipart/form-data) в конец галереи.
+// Файл проверяется декодированием, сохраняются только перекодированные копии без метаданных.
+func (service *PhotosService) UploadPhoto(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+	userID := userClaims.UserID
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.cfg.MaxUploadSize+multipartOverhead)
+
+	file, header, err := c.Request.FormFile("photo")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			service.tooLarge(c)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Photo file is required"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > service.cfg.MaxUploadSize {
+		service.tooLarge(c)
+		return
+	}
+
+	// Проверка лимита до обработки; окончательно он проверяется при сохранении
+	photos, err := service.repo.GetUserPhotos(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo"})
+		service.log.Error(err.Error())
+		return
+	}
+	if len(photos) >= service.cfg.MaxPhotos {
+		service.limitReached(c)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, service.cfg.MaxUploadSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read photo"})
+		return
+	}
+	if int64(len(data)) > service.cfg.MaxUploadSize {
+		service.tooLarge(c)
+		return
+	}
+
+	processed, err := service.processor.Process(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, images.ErrUnsupportedFormat):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Photo must be a JPEG, PNG or WebP image"})
+		case errors.Is(err, images.ErrImageTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Photo resolution is too large"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo"})
+			service.log.Error(err.Error())
+		}
+		return
+	}
+
+	variants, err := service.store(c.Request.Context(), processed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	photo, err := service.repo.AddProfilePhoto(c.Request.Context(), userID, variants, service.avatarURL(variants), service.cfg.MaxPhotos)
+	if err != nil {
+		// Строки в базе нет, поэтому файлы удаляем сразу, а не через очередь
+		service.discard(variants)
+
+		switch {
+		case errors.Is(err, repository.ErrPhotoLimitReached):
+			service.limitReached(c)
+		case errors.Is(err, repository.ErrProfileNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo"})
+			service.log.Error(err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, photo)
+}
+
+// ReorderPhotos расставляет фотографии галереи в порядке photo_ids
+func (service *PhotosService) ReorderPhotos(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	var orderData struct {
+		PhotoIDs []int `json:"photo_ids"`
+	}
+
+	if err := c.ShouldBindJSON(&orderData); err != nil || orderData.PhotoIDs == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	photos, err := service.repo.ReorderProfilePhotos(c.Request.Context(), userClaims.UserID, orderData.PhotoIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPhotoOrderMismatch):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "photo_ids must list every photo of the gallery exactly once"})
+		case errors.Is(err, repository.ErrProfileNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder photos"})
+			service.log.Error(err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"photos": photos})
+}
+
+// SetPrimaryPhoto делает фотографию основной; ее копия становится аватаром анкеты
+func (service *PhotosService) SetPrimaryPhoto(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	photoID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID"})
+		return
+	}
+
+	if err := service.repo.SetPrimaryPhoto(c.Request.Context(), userClaims.UserID, photoID); err != nil {
+		service.photoWriteFailed(c, err, "Failed to set primary photo")
+		return
+	}
+
+	service.GetPhotos(c)
+}
+
+// DeletePhoto удаляет фотографию из галереи. Файлы удаляются из хранилища в фоне.
+func (service *PhotosService) DeletePhoto(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	photoID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID"})
+		return
+	}
+
+	if err := service.repo.DeleteProfilePhoto(c.Request.Context(), userClaims.UserID, photoID); err != nil {
+		service.photoWriteFailed(c, err, "Failed to delete photo")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// store сохраняет копии фотографии в хранилище под общим случайным префиксом.
+// Если одну из копий сохранить не удалось, уже сохраненные удаляются.
+func (service *PhotosService) store(ctx context.Context, processed []images.Variant) ([]models.PhotoVariant, error) {
+	prefix, err := newPhotoPrefix()
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make([]models.PhotoVariant, 0, len(processed))
+	for _, variant := range processed {
+		key := prefix + "/" + strconv.Itoa(variant.Size) + ".jpg"
+
+		if err := service.storage.Put(ctx, key, variant.Data, "image/jpeg"); err != nil {
+			service.discard(variants)
+			return nil, err
+		}
+
+		variants = append(variants, models.PhotoVariant{
+			Size:   variant.Size,
+			Width:  variant.Width,
+			Height: variant.Height,
+			URL:    service.storage.URL(key),
+			Key:    key,
+		})
+	}
+
+	return variants, nil
+}
+
+// discard удаляет из хранилища файлы фотографии, которая не попала в галерею
+func (service *PhotosService) discard(variants []models.PhotoVariant) {
+	for _, variant := range variants {
+		if err := service.storage.Delete(context.Background(), variant.Key); err != nil {
+			service.log.Error(err.Error())
+		}
+	}
+}
+
+// avatarURL адрес копии размера AvatarSize, а если такой нет - самой большой копии
+func (service *PhotosService) avatarURL(variants []models.PhotoVariant) string {
+	var largest models.PhotoVariant
+	for _, variant := range variants {
+		if variant.Size == service.cfg.AvatarSize {
+			return variant.URL
+		}
+		if variant.Size > largest.Size {
+			largest = variant
+		}
+	}
+
+	return largest.URL
+}
+
+func (service *PhotosService) tooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error": "Photo must be at most " + strconv.FormatInt(service.cfg.MaxUploadSize>>20, 10) + " MB",
+	})
+}
+
+func (service *PhotosService) limitReached(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error": "Gallery is full, at most " + strconv.Itoa(service.cfg.MaxPhotos) + " photos are allowed",
+	})
+}
+
+// photoWriteFailed отвечает на ошибку изменения фотографии из галереи
+func (service *PhotosService) photoWriteFailed(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrPhotoNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Photo not found"})
+	case errors.Is(err, repository.ErrProfileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+		service.log.Error(err.Error())
+	}
+}
+
+// newPhotoPrefix случайный префикс ключей: по адресу фотографии не узнать ни анкету, ни соседние фотографии
+func newPhotoPrefix() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "photos/" + hex.EncodeToString(b), nil
+}
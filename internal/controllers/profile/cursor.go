@@ -0,0 +1,32 @@
+package profile
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	models "passion-pals-backend/internal/models"
+)
+
+// encodeCursor упаковывает позицию в ленте в непрозрачную для клиента строку
+func encodeCursor(cursor *models.ProfileCursor) string {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor разбирает курсор из параметра cursor
+func decodeCursor(value string) (*models.ProfileCursor, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, false
+	}
+
+	var cursor models.ProfileCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 || cursor.Key.IsZero() {
+		return nil, false
+	}
+
+	return &cursor, true
+}
@@ -0,0 +1,213 @@
+package profile
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"passion-pals-backend/internal/config"
+	models "passion-pals-backend/internal/models"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := &models.ProfileCursor{
+		Sort: models.FeedSortAgeAsc,
+		Key:  time.Date(2026, 10, 18, 12, 30, 0, 123456000, time.UTC),
+		ID:   42,
+	}
+
+	decoded, ok := decodeCursor(encodeCursor(cursor))
+	if !ok {
+		t.Fatal("decodeCursor() rejected an encoded cursor")
+	}
+	if decoded.Sort != cursor.Sort || !decoded.Key.Equal(cursor.Key) || decoded.ID != cursor.ID {
+		t.Errorf("decodeCursor() = %+v, want %+v", decoded, cursor)
+	}
+}
+
+func TestDecodeCursorRejectsInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "!!!"},
+		{"not json", encode("cursor")},
+		{"no id", encode(`{"s":"recent","k":"2026-10-18T12:00:00Z"}`)},
+		{"negative id", encode(`{"s":"recent","k":"2026-10-18T12:00:00Z","id":-1}`)},
+		{"no key", encode(`{"s":"recent","id":1}`)},
+		{"bad key", encode(`{"s":"recent","k":"yesterday","id":1}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cursor, ok := decodeCursor(tt.value); ok {
+				t.Errorf("decodeCursor() = %+v, want rejection", cursor)
+			}
+		})
+	}
+}
+
+func TestETagRoundTrip(t *testing.T) {
+	updatedAt := time.Date(2026, 10, 18, 12, 30, 0, 123456789, time.UTC)
+
+	parsed, ok := parseETag(etag(updatedAt))
+	if !ok || !parsed.Equal(updatedAt.Truncate(time.Microsecond)) {
+		t.Errorf("parseETag(etag()) = %v, %v", parsed, ok)
+	}
+
+	tests := []struct {
+		header string
+		ok     bool
+	}{
+		{`"1760790600123456"`, true},
+		{` "1760790600123456" , "1"`, true},
+		{`W/"1760790600123456"`, false},
+		{`1760790600123456`, false},
+		{`"abc"`, false},
+		{`""`, false},
+		{`*`, false},
+		{``, false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := parseETag(tt.header); ok != tt.ok {
+			t.Errorf("parseETag(%q) ok = %v, want %v", tt.header, ok, tt.ok)
+		}
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"72h", 72 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"d", 0, true},
+		{"1.5d", 0, true},
+		{"week", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseWindow(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseWindow(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+// parseFilter разбирает параметры ленты из query и возвращает фильтр и код ответа (0 - ответа не было)
+func parseFilter(t *testing.T, query string) (models.ProfileFilter, int) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	profile := &ProfileService{feed: config.FeedConfig{
+		DefaultPageSize:     20,
+		MaxPageSize:         100,
+		DefaultActiveWithin: 7 * 24 * time.Hour,
+		MaxWithinKm:         500,
+	}}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/profiles?"+query, nil)
+
+	filter, ok := profile.parseFeedFilter(c)
+	if ok {
+		return filter, 0
+	}
+
+	return filter, w.Code
+}
+
+func TestParseFeedFilter(t *testing.T) {
+	filter, status := parseFilter(t, "")
+	if status != 0 {
+		t.Fatalf("default filter rejected with %d", status)
+	}
+	if filter.Sort != models.FeedSortRecent || filter.Limit != 20 || filter.ActiveSince == nil || filter.After != nil {
+		t.Errorf("unexpected default filter: %+v", filter)
+	}
+
+	cursor := encodeCursor(&models.ProfileCursor{Sort: models.FeedSortNewest, Key: time.Now(), ID: 7})
+	filter, status = parseFilter(t, "sort=newest&limit=5&min_age=20&max_age=30&gender=%20Female&looking_for=friends&active_within=2d&interests_match=all&cursor="+cursor)
+	if status != 0 {
+		t.Fatalf("filter rejected with %d", status)
+	}
+	if filter.Sort != models.FeedSortNewest || filter.Limit != 5 || filter.MinAge != 20 || filter.MaxAge != 30 ||
+		filter.Gender != "female" || filter.LookingFor != "friends" || !filter.MatchAllInterests ||
+		filter.After == nil || filter.After.ID != 7 {
+		t.Errorf("unexpected filter: %+v", filter)
+	}
+	if since := time.Since(*filter.ActiveSince); since < 48*time.Hour || since > 49*time.Hour {
+		t.Errorf("ActiveSince is %v ago, want 48h", since)
+	}
+
+	filter, status = parseFilter(t, "within_km=25")
+	if status != 0 || filter.WithinKm != 25 {
+		t.Errorf("within_km=25: status = %d, WithinKm = %d", status, filter.WithinKm)
+	}
+
+	otherSort := encodeCursor(&models.ProfileCursor{Sort: models.FeedSortRecent, Key: time.Now(), ID: 7})
+
+	for _, query := range []string{
+		"sort=random",
+		"limit=0",
+		"limit=101",
+		"limit=ten",
+		"min_age=0",
+		"max_age=151",
+		"min_age=30&max_age=20",
+		"active_within=-1h",
+		"active_within=soon",
+		"interests_match=some",
+		"within_km=0",
+		"within_km=-5",
+		"within_km=501",
+		"within_km=2.5",
+		"within_km=1e1",
+		"cursor=garbage",
+		"sort=newest&cursor=" + otherSort,
+	} {
+		if _, status := parseFilter(t, query); status != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, status, http.StatusBadRequest)
+		}
+	}
+}
+
+// Повтор того же местоположения (в том числе сырыми координатами) не считается сменой
+func TestSameLocation(t *testing.T) {
+	point := func(lat, lon float64) *models.ProfileLocation {
+		return &models.ProfileLocation{Latitude: &lat, Longitude: &lon}
+	}
+	current := &models.GeoPoint{Latitude: 55.751244, Longitude: 37.618423}
+
+	tests := []struct {
+		name     string
+		current  *models.GeoPoint
+		location *models.ProfileLocation
+		want     bool
+	}{
+		{"same raw coordinates", current, point(55.751244, 37.618423), true},
+		{"same grid cell", current, point(55.7531, 37.6152), true},
+		{"already snapped", current, point(55.75, 37.62), true},
+		{"neighbouring cell", current, point(55.76, 37.62), false},
+		{"no current location", nil, point(55.751244, 37.618423), false},
+		{"coordinates removed", current, &models.ProfileLocation{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameLocation(tt.current, tt.location); got != tt.want {
+				t.Errorf("sameLocation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
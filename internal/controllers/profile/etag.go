@@ -0,0 +1,30 @@
+package profile
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// etag версия анкеты для заголовков ETag/If-Match: updated_at с точностью Postgres (микросекунды)
+func etag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 10) + `"`
+}
+
+// parseETag возвращает updated_at из значения If-Match. Из списка значений
+// берется первое, слабые ETag (W/) не принимаются: версия должна совпадать точно.
+func parseETag(header string) (time.Time, bool) {
+	value, _, _ := strings.Cut(header, ",")
+	value = strings.TrimSpace(value)
+
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return time.Time{}, false
+	}
+
+	micros, err := strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.UnixMicro(micros).UTC(), true
+}
@@ -0,0 +1,110 @@
+package profile
+
+import (
+	"context"
+	"net/http"
+	"passion-pals-backend/internal/utils/middleware"
+	"passion-pals-backend/internal/utils/validation"
+	"slices"
+	"strconv"
+	"strings"
+
+	models "passion-pals-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPreferences возвращает предпочтения подбора текущего пользователя
+func (profile *ProfileService) GetPreferences(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	prefs, err := profile.repo.GetDiscoveryPreferences(c.Request.Context(), userClaims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get preferences"})
+		profile.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// PutPreferences заменяет предпочтения подбора текущего пользователя: непереданные поля
+// снимают соответствующее ограничение. Анкета показывается в ленте, только если
+// предпочтения подходят взаимно.
+func (profile *ProfileService) PutPreferences(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	var prefsData struct {
+		MinAge        *int     `json:"min_age"`
+		MaxAge        *int     `json:"max_age"`
+		Genders       []string `json:"genders"`
+		MaxDistanceKm *int     `json:"max_distance_km"`
+		Intent        string   `json:"intent"`
+	}
+
+	if err := c.ShouldBindJSON(&prefsData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	genders := make([]string, 0, len(prefsData.Genders))
+	for _, gender := range prefsData.Genders {
+		genders = append(genders, strings.ToLower(strings.TrimSpace(gender)))
+	}
+
+	prefs := models.DiscoveryPreferences{
+		MinAge:        prefsData.MinAge,
+		MaxAge:        prefsData.MaxAge,
+		Genders:       slices.Compact(slices.Sorted(slices.Values(genders))),
+		MaxDistanceKm: prefsData.MaxDistanceKm,
+		Intent:        strings.ToLower(strings.TrimSpace(prefsData.Intent)),
+	}
+
+	var errs validation.Errors
+	profile.policy.AgeRange(&errs, "min_age", "max_age", prefs.MinAge, prefs.MaxAge)
+	profile.policy.Genders(&errs, "genders", prefs.Genders)
+	if prefs.MaxDistanceKm != nil && (*prefs.MaxDistanceKm <= 0 || *prefs.MaxDistanceKm > profile.feed.MaxWithinKm) {
+		errs.Add("max_distance_km", validation.CodeInvalid, "Max distance must be between 1 and "+strconv.Itoa(profile.feed.MaxWithinKm))
+	}
+	profile.policy.OneOf(&errs, "intent", prefs.Intent, models.RelationshipIntents)
+
+	if !errs.Empty() {
+		c.JSON(http.StatusUnprocessableEntity, errs.Response())
+		return
+	}
+
+	saved, err := profile.repo.SetDiscoveryPreferences(c.Request.Context(), userClaims.UserID, prefs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save preferences"})
+		profile.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// mutualMatch собирает данные для взаимного подбора анкет пользователю userID.
+// viewer - его анкета, nil, если анкеты нет (тогда под предпочтения с ограничениями
+// по возрасту или полу он не подходит).
+func (profile *ProfileService) mutualMatch(ctx context.Context, userID int, viewer *models.UserProfile) (*models.MutualMatch, error) {
+	prefs, err := profile.repo.GetDiscoveryPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	match := &models.MutualMatch{Preferences: *prefs}
+	if viewer != nil {
+		match.Age = viewer.Age
+		match.Gender = viewer.Gender
+	}
+
+	return match, nil
+}
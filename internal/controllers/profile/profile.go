@@ -1,143 +1,671 @@
 package profile
 
 import (
+	"encoding/json"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"passion-pals-backend/internal/config"
+	models "passion-pals-backend/internal/models"
 	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/audit"
+	"passion-pals-backend/internal/utils/deletion"
+	"passion-pals-backend/internal/utils/middleware"
+	"passion-pals-backend/internal/utils/password"
+	"passion-pals-backend/internal/utils/recommend"
+	"passion-pals-backend/internal/utils/revocation"
+	"passion-pals-backend/internal/utils/throttle"
+	"passion-pals-backend/internal/utils/validation"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type ProfileService struct {
-	log  *slog.Logger
-	repo *repository.Repository
+	log       *slog.Logger
+	repo      *repository.Repository
+	revoked   *revocation.List
+	purger    *deletion.Purger
+	events    *audit.Recorder
+	policy    *validation.Policy
+	passwords *password.Hasher
+	limiter   *throttle.Limiter
+	feed      config.FeedConfig
+	ranker    *recommend.Ranker
+	tokenTTL  time.Duration
+
+	recommendations config.RecommendationsConfig
 }
 
-func New(log *slog.Logger, repo *repository.Repository) *ProfileService {
+func New(
+	log *slog.Logger,
+	repo *repository.Repository,
+	revoked *revocation.List,
+	purger *deletion.Purger,
+	events *audit.Recorder,
+	policy *validation.Policy,
+	passwords *password.Hasher,
+	limiter *throttle.Limiter,
+	feed config.FeedConfig,
+	recommendations config.RecommendationsConfig,
+	tokenTTL time.Duration,
+) *ProfileService {
 	return &ProfileService{
-		log:  log,
-		repo: repo,
+		log:       log,
+		repo:      repo,
+		revoked:   revoked,
+		purger:    purger,
+		events:    events,
+		policy:    policy,
+		passwords: passwords,
+		limiter:   limiter,
+		feed:      feed,
+		ranker:    recommend.New(recommendations),
+		tokenTTL:  tokenTTL,
+
+		recommendations: recommendations,
 	}
 }
 
 func (profile *ProfileService) GetUserProfile(c *gin.Context) {
 	// Извлекаем user_id из контекста
-	claims, exists := c.Get("userClaims")
-	if !exists {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
 		return
 	}
+	userID := userClaims.UserID
+
+	// Используем userID для получения профиля
+	userProfile, err := profile.repo.GetProfileByUserId(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrProfileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user profile"})
+		profile.log.Error(err.Error())
+		return
+	}
 
-	// Приводим к map[string]interface{} сначала
-	userClaims, ok := claims.(jwt.MapClaims)
+	// ETag передается в If-Match при изменении анкеты
+	c.Header("ETag", etag(userProfile.UpdatedAt))
+	c.JSON(http.StatusOK, userProfile)
+}
+
+// GetProfileByID возвращает анкету по id с учетом настроек приватности владельца.
+// Анкеты удаленных учетных записей не показываются.
+func (profile *ProfileService) GetProfileByID(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": " Invalid claims format"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
 		return
 	}
 
-	// Теперь можно безопасно извлекать данные
-	userIDFloat, ok := userClaims["user_id"].(float64)
-	userID := int(userIDFloat)
+	profileID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || profileID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid profile ID"})
+		return
+	}
+
+	userProfile, err := profile.repo.GetProfileByID(c.Request.Context(), profileID)
+	if err != nil {
+		if errors.Is(err, repository.ErrProfileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user profile"})
+		profile.log.Error(err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, userProfile.ViewFor(userClaims.UserID))
+}
 
+// GetProfiles возвращает страницу ленты анкет с учетом настроек приватности владельцев.
+// Параметры: limit, cursor (из next_cursor предыдущей страницы), min_age, max_age, gender,
+// looking_for, active_within (например, 72h или 7d), interests (через запятую) с interests_match
+// (any или all), within_km (нужно указать свое местоположение), sort (recent, newest, age_asc, age_desc).
+// Если у пользователя указаны координаты, для анкет возвращается округленное расстояние distance_km.
+// Показываются только анкеты, взаимно подходящие по предпочтениям подбора (GET /profile/preferences).
+func (profile *ProfileService) GetProfiles(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": " User ID not found in token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
 		return
 	}
 
-	// Используем userID для получения профиля
-	userProfile, err := profile.repo.GetProfileByUserId(c.Request.Context(), userID)
+	filter, ok := profile.parseFeedFilter(c)
+	if !ok {
+		return
+	}
+	filter.ViewerID = userClaims.UserID
+
+	// Без анкеты пользователь все равно может смотреть ленту
+	viewer, err := profile.repo.GetProfileByUserId(c.Request.Context(), userClaims.UserID)
+	if err != nil && !errors.Is(err, repository.ErrProfileNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user profile"})
+		profile.log.Error(err.Error())
+		return
+	}
+	if viewer != nil {
+		filter.Origin = viewer.Location()
+	}
+	if filter.WithinKm > 0 && filter.Origin == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set your location to filter by distance"})
+		return
+	}
+
+	filter.Mutual, err = profile.mutualMatch(c.Request.Context(), userClaims.UserID, viewer)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user profile"})
+		profile.log.Error(err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, userProfile)
+	profiles, next, err := profile.repo.GetProfiles(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user profile"})
+		profile.log.Error(err.Error())
+		return
+	}
+
+	// Скрытые владельцами поля не показываются
+	views := make([]any, 0, len(profiles))
+	for _, userProfile := range profiles {
+		views = append(views, userProfile.ViewFor(userClaims.UserID))
+	}
+
+	var nextCursor *string
+	if next != nil {
+		encoded := encodeCursor(next)
+		nextCursor = &encoded
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"profiles":    views,
+		"next_cursor": nextCursor,
+	})
 }
 
-func (profile *ProfileService) GetProfileByID(c *gin.Context) { /*
-		// Извлекаем user_id из контекста
-		userID := c.Param("id")
+// parseFeedFilter разбирает параметры ленты. При ошибке ответ уже отправлен клиенту.
+func (profile *ProfileService) parseFeedFilter(c *gin.Context) (models.ProfileFilter, bool) {
+	filter := models.ProfileFilter{
+		Gender:     strings.ToLower(strings.TrimSpace(c.Query("gender"))),
+		LookingFor: strings.TrimSpace(c.Query("looking_for")),
+		Sort:       c.DefaultQuery("sort", models.FeedSortRecent),
+		Limit:      profile.feed.DefaultPageSize,
+	}
 
-		userProfile, err := profile.repo.GetProfileByUserId(c.Request.Context(), userID)
+	switch filter.Sort {
+	case models.FeedSortRecent, models.FeedSortNewest, models.FeedSortAgeAsc, models.FeedSortAgeDesc:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown sort, expected one of recent, newest, age_asc, age_desc"})
+		return filter, false
+	}
+
+	if param := c.Query("limit"); param != "" {
+		limit, err := strconv.Atoi(param)
+		if err != nil || limit <= 0 || limit > profile.feed.MaxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Limit must be between 1 and " + strconv.Itoa(profile.feed.MaxPageSize)})
+			return filter, false
+		}
+		filter.Limit = limit
+	}
+
+	for name, target := range map[string]*int{"min_age": &filter.MinAge, "max_age": &filter.MaxAge} {
+		param := c.Query(name)
+		if param == "" {
+			continue
+		}
+
+		age, err := strconv.Atoi(param)
+		if err != nil || age <= 0 || age > 150 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+			return filter, false
+		}
+		*target = age
+	}
+	if filter.MinAge > 0 && filter.MaxAge > 0 && filter.MinAge > filter.MaxAge {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_age cannot be greater than max_age"})
+		return filter, false
+	}
+
+	activeWithin := profile.feed.DefaultActiveWithin
+	if param := c.Query("active_within"); param != "" {
+		var err error
+		activeWithin, err = parseWindow(param)
+		if err != nil || activeWithin <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid active_within, expected a duration like 72h or 7d"})
+			return filter, false
+		}
+	}
+	if activeWithin > 0 {
+		activeSince := time.Now().Add(-activeWithin)
+		filter.ActiveSince = &activeSince
+	}
+
+	// Только целые километры: дробный радиус позволил бы уточнять положение анкеты
+	if param := c.Query("within_km"); param != "" {
+		withinKm, err := strconv.Atoi(param)
+		if err != nil || withinKm <= 0 || withinKm > profile.feed.MaxWithinKm {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "within_km must be a whole number between 1 and " + strconv.Itoa(profile.feed.MaxWithinKm)})
+			return filter, false
+		}
+		filter.WithinKm = withinKm
+	}
+
+	// interests=hiking,photo&interests_match=any|all
+	if param := c.Query("interests"); param != "" {
+		interestIDs, unknown, err := profile.repo.ResolveInterests(c.Request.Context(), strings.Split(param, ","))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user profile"})
-			return
+			profile.log.Error(err.Error())
+			return filter, false
+		}
+		if len(unknown) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown interests", "interests": unknown})
+			return filter, false
+		}
+		filter.InterestIDs = interestIDs
+	}
+
+	switch c.DefaultQuery("interests_match", "any") {
+	case "any":
+	case "all":
+		filter.MatchAllInterests = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interests_match, expected any or all"})
+		return filter, false
+	}
+
+	if param := c.Query("cursor"); param != "" {
+		cursor, ok := decodeCursor(param)
+		if !ok || cursor.Sort != filter.Sort {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return filter, false
+		}
+		filter.After = cursor
+	}
+
+	return filter, true
+}
+
+// parseWindow разбирает длительность в формате time.ParseDuration или в днях ("7d")
+func parseWindow(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
 		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
 
-		c.JSON(http.StatusOK, userProfile) */
+	return time.ParseDuration(value)
 }
 
-func (profile *ProfileService) GetProfiles(c *gin.Context) {
-	// Получаем все анкеты пользователей
-	profiles, err := profile.repo.GetProfiles(c.Request.Context())
+// GetRecommendedProfiles возвращает анкеты, ранжированные для текущего пользователя, с разбивкой
+// оценки по компонентам. Параметр limit ограничивает число анкет. Как и в ленте, рекомендуются
+// только анкеты, взаимно подходящие по предпочтениям подбора.
+func (profile *ProfileService) GetRecommendedProfiles(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	limit := profile.recommendations.DefaultPageSize
+	if param := c.Query("limit"); param != "" {
+		var err error
+		limit, err = strconv.Atoi(param)
+		if err != nil || limit <= 0 || limit > profile.recommendations.MaxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Limit must be between 1 and " + strconv.Itoa(profile.recommendations.MaxPageSize)})
+			return
+		}
+	}
+
+	viewer, err := profile.repo.GetProfileByUserId(c.Request.Context(), userClaims.UserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user profile"})
+		if errors.Is(err, repository.ErrProfileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recommendations"})
+		profile.log.Error(err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, profiles)
+	match, err := profile.mutualMatch(c.Request.Context(), userClaims.UserID, viewer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recommendations"})
+		profile.log.Error(err.Error())
+		return
+	}
+
+	candidates, err := profile.repo.GetRecommendationCandidates(c.Request.Context(), userClaims.UserID, viewer.Location(),
+		match, profile.recommendations.CandidatePoolSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recommendations"})
+		profile.log.Error(err.Error())
+		return
+	}
+
+	results := profile.ranker.Rank(viewer, candidates, limit)
+
+	recommendations := make([]gin.H, 0, len(results))
+	for _, result := range results {
+		recommendations = append(recommendations, gin.H{
+			"profile":          result.Profile.ViewFor(userClaims.UserID),
+			"score":            result.Score,
+			"breakdown":        result.Breakdown,
+			"shared_interests": result.SharedInterests,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recommendations": recommendations})
 }
 
+// EditUserProfile изменяет анкету текущего пользователя. PUT заменяет все изменяемые поля
+// (отсутствующие очищаются), PATCH - только переданные. Заголовок If-Match с ETag из
+// GET /profile обязателен: если анкету успели изменить с другого устройства, ответ - 412.
 func (profile *ProfileService) EditUserProfile(c *gin.Context) {
-	// Извлекаем user_id из контекста
-	/*
-		userID, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
-			profile.log.Debug("User ID not found in token")
-			return
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+	userID := userClaims.UserID
+
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
+	var profileData struct {
+		AboutMe    *string `json:"about_me"`
+		AvatarURL  *string `json:"avatar_url"`
+		Gender     *string `json:"gender"`
+		LookingFor *string `json:"looking_for"`
+		// Настройки приватности: поля, скрытые от других пользователей
+		HiddenFields *[]string `json:"hidden_fields"`
+		// Местоположение: {"latitude", "longitude", "city"}, null удаляет его
+		Location json.RawMessage `json:"location"`
+	}
+
+	if err := c.ShouldBindJSON(&profileData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	update := models.ProfileUpdate{
+		AboutMe:    profileData.AboutMe,
+		AvatarURL:  profileData.AvatarURL,
+		Gender:     profileData.Gender,
+		LookingFor: profileData.LookingFor,
+	}
+	if profileData.HiddenFields != nil {
+		update.HiddenFields = slices.Compact(slices.Sorted(slices.Values(*profileData.HiddenFields)))
+	}
+	if len(profileData.Location) > 0 {
+		var location struct {
+			Latitude  *float64 `json:"latitude"`
+			Longitude *float64 `json:"longitude"`
+			City      string   `json:"city"`
+		}
+		if string(profileData.Location) != "null" {
+			if err := json.Unmarshal(profileData.Location, &location); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+				return
+			}
+		}
+		update.Location = &models.ProfileLocation{
+			Latitude:  location.Latitude,
+			Longitude: location.Longitude,
+			City:      strings.TrimSpace(location.City),
+		}
+	}
+
+	// PUT заменяет анкету целиком: непереданные поля очищаются (пол обязателен и не пройдет проверку)
+	if c.Request.Method == http.MethodPut {
+		for _, field := range []**string{&update.AboutMe, &update.AvatarURL, &update.Gender, &update.LookingFor} {
+			if *field == nil {
+				*field = new(string)
+			}
 		}
+		if update.HiddenFields == nil {
+			update.HiddenFields = []string{}
+		}
+		if update.Location == nil {
+			update.Location = &models.ProfileLocation{}
+		}
+	}
+
+	var errs validation.Errors
+	if update.AboutMe != nil {
+		*update.AboutMe = strings.TrimSpace(*update.AboutMe)
+		profile.policy.AboutMe(&errs, "about_me", *update.AboutMe)
+	}
+	if update.AvatarURL != nil {
+		*update.AvatarURL = strings.TrimSpace(*update.AvatarURL)
+		profile.policy.AvatarURL(&errs, "avatar_url", *update.AvatarURL)
+	}
+	if update.Gender != nil {
+		*update.Gender = strings.ToLower(strings.TrimSpace(*update.Gender))
+		profile.policy.Gender(&errs, "gender", *update.Gender)
+	}
+	if update.LookingFor != nil {
+		*update.LookingFor = strings.TrimSpace(*update.LookingFor)
+		profile.policy.LookingFor(&errs, "looking_for", *update.LookingFor)
+	}
+	if update.Location != nil {
+		profile.policy.Location(&errs, "location", update.Location.Latitude, update.Location.Longitude, update.Location.City)
+	}
+	profile.policy.HiddenFields(&errs, "hidden_fields", update.HiddenFields, models.HideableProfileFields)
+
+	if !errs.Empty() {
+		c.JSON(http.StatusUnprocessableEntity, errs.Response())
+		return
+	}
+
+	if update.Location != nil && !profile.checkLocationChange(c, userID, update.Location) {
+		return
+	}
 
-		// Преобразуем userID в string
-		userIdStr, ok := userID.(string)
+	// If-Match: * разрешает изменение без сравнения версий
+	var expectedUpdatedAt time.Time
+	if ifMatch == "*" {
+		current, err := profile.repo.GetProfileByUserId(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile"})
+			profile.log.Error(err.Error())
+			return
+		}
+		expectedUpdatedAt = current.UpdatedAt
+	} else {
+		var ok bool
+		expectedUpdatedAt, ok = parseETag(ifMatch)
 		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
-			profile.log.Debug("Invalid user ID format")
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Profile has been modified, reload it and try again"})
 			return
 		}
+	}
 
-		// Используем userID для получения профиля
-		userProfile, err := profile.repo.GetProfileByUserId(c.Request.Context(), userIdStr)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user profile"})
+	updated, err := profile.repo.UpdateProfile(c.Request.Context(), userID, update, expectedUpdatedAt)
+	if err != nil {
+		if errors.Is(err, repository.ErrProfileModified) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Profile has been modified, reload it and try again"})
+			return
+		}
+		if errors.Is(err, repository.ErrProfileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile"})
+		profile.log.Error(err.Error())
+		return
+	}
+
+	c.Header("ETag", etag(updated.UpdatedAt))
+	c.JSON(http.StatusOK, updated)
+}
+
+// checkLocationChange отвечает 429 с Retry-After, если координаты меняются раньше, чем через
+// LocationChangeInterval после прошлого изменения. Удалить координаты или повторить прежние можно всегда.
+// Одновременные изменения не обходят ограничение: UpdateProfile сравнивает версию анкеты.
+func (profile *ProfileService) checkLocationChange(c *gin.Context, userID int, location *models.ProfileLocation) bool {
+	if location.Latitude == nil || location.Longitude == nil {
+		return true
+	}
+
+	current, updatedAt, err := profile.repo.GetLocationChange(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrProfileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile"})
+		profile.log.Error(err.Error())
+		return false
+	}
+
+	if sameLocation(current, location) {
+		return true
+	}
+	if updatedAt == nil {
+		return true
+	}
+
+	retryAfter := time.Until(updatedAt.Add(profile.feed.LocationChangeInterval))
+	if retryAfter <= 0 {
+		return true
+	}
 
-		c.JSON(http.StatusOK, userProfile)
-	*/
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Location was changed recently, try again later",
+		"retry_after": seconds,
+	})
+
+	return false
+}
+
+// sameLocation сообщает, совпадают ли координаты после привязки к сетке: в выдаче видны только
+// привязанные координаты, поэтому сдвиг в пределах ячейки местоположение не меняет
+func sameLocation(current *models.GeoPoint, location *models.ProfileLocation) bool {
+	if current == nil || location.Latitude == nil || location.Longitude == nil {
+		return false
+	}
+
+	return current.Snapped() == (models.GeoPoint{Latitude: *location.Latitude, Longitude: *location.Longitude}).Snapped()
 }
 
 func (profile *ProfileService) DeleteUserProfile(c *gin.Context) {
 	// Извлекаем user_id из контекста
-	claims, exists := c.Get("userClaims")
-	if !exists {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
 		return
 	}
+	userID := userClaims.UserID
 
-	// Приводим к map[string]interface{} сначала
-	userClaims, ok := claims.(jwt.MapClaims)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid claims format"})
+	if !profile.confirmPassword(c, userID) {
 		return
 	}
 
-	// Теперь можно безопасно извлекать данные
-	userIDFloat, ok := userClaims["user_id"].(float64)
-	userID := int(userIDFloat)
+	// Учетная запись только помечается удаленной: до истечения срока ее можно
+	// восстановить через POST /account/restore, потом данные удалит фоновая задача
+	deletedAt := time.Now()
 
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+	err := profile.repo.MarkUserDeleted(c.Request.Context(), userID, deletedAt)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserAlreadyDeleted) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Account is already scheduled for deletion"})
+			return
+		}
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user profile"})
+		profile.log.Error(err.Error())
 		return
 	}
 
-	// Используем userID для удаления профиля
-	err := profile.repo.DeleteUserByID(c.Request.Context(), userID)
+	profile.events.Record(c, audit.EventAccountDeleted, userID, gin.H{"purge_at": profile.purger.PurgeAt(deletedAt)})
+
+	// Сессии и API-ключи отозваны в базе, осталось отозвать выданные access-токены
+	if err := profile.revoked.RevokeUser(c.Request.Context(), userID, profile.tokenTTL); err != nil {
+		profile.log.Error(err.Error())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Account is scheduled for deletion",
+		"purge_at": profile.purger.PurgeAt(deletedAt),
+	})
+}
+
+// confirmPassword проверяет текущий пароль из тела запроса перед удалением учетной записи.
+// Подбор ограничивается так же, как подбор при входе; при отказе ответ уже отправлен.
+func (profile *ProfileService) confirmPassword(c *gin.Context, userID int) bool {
+	var confirmData struct {
+		CurrentPassword string `json:"current_password"`
+	}
+
+	if err := c.ShouldBindJSON(&confirmData); err != nil || confirmData.CurrentPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Current password is required"})
+		return false
+	}
+
+	user, err := profile.repo.FindUserByID(c.Request.Context(), userID)
 	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return false
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user profile"})
-		return
+		profile.log.Error(err.Error())
+		return false
+	}
+
+	retryAfter, err := profile.limiter.Check(c.Request.Context(), user.Email, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user profile"})
+		profile.log.Error(err.Error())
+		return false
+	}
+	if retryAfter > 0 {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many failed attempts, try again later",
+			"retry_after": seconds,
+		})
+		return false
+	}
+
+	match, err := profile.passwords.Verify(confirmData.CurrentPassword, user.PasswordHash)
+	if err != nil {
+		profile.log.Error(err.Error())
+	}
+	if !match {
+		if err := profile.limiter.Fail(c.Request.Context(), user.Email, c.ClientIP()); err != nil {
+			profile.log.Error(err.Error())
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+		return false
+	}
+
+	if err := profile.limiter.Succeed(c.Request.Context(), user.Email); err != nil {
+		profile.log.Error(err.Error())
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User is sucessifuly deleted"})
+	return true
 }
@@ -4,9 +4,9 @@ import (
 	"log/slog"
 	"net/http"
 	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/middleware"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
 )
 
 type ResponsesService struct {
@@ -53,27 +53,13 @@ func (response *ResponsesService) RejectResponse(c *gin.Context) {
 }
 
 func (response *ResponsesService) GetResponses(c *gin.Context) {
-	claims, exists := c.Get("userClaims")
-	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
-		return
-	}
-
-	// Приводим к map[string]interface{} сначала
-	userClaims, ok := claims.(jwt.MapClaims)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid claims format"})
-		return
-	}
-
-	// Теперь можно безопасно извлекать данные
-	userIDFloat, ok := userClaims["user_id"].(float64)
-	userID := int(userIDFloat)
-
+	// Извлекаем user_id из контекста
+	userClaims, ok := middleware.ClaimsFromContext(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
 		return
 	}
+	userID := userClaims.UserID
 
 	// Используем userID для получения профиля
 	incomingResponses, err := response.repo.GetIncomingResponses(c.Request.Context(), userID)
@@ -0,0 +1,132 @@
+package security
+
+import (
+	"log/slog"
+	"net/http"
+	models "passion-pals-backend/internal/models"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/middleware"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// SecurityService просмотр журнала событий безопасности
+type SecurityService struct {
+	log  *slog.Logger
+	repo *repository.Repository
+}
+
+func New(log *slog.Logger, repo *repository.Repository) *SecurityService {
+	return &SecurityService{
+		log:  log,
+		repo: repo,
+	}
+}
+
+// ListOwnEvents возвращает события учетной записи текущего пользователя.
+// Фильтры: type, since, until (RFC 3339); постраничный вывод: limit и before (id последнего события).
+func (service *SecurityService) ListOwnEvents(c *gin.Context) {
+	userClaims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User claims not found"})
+		return
+	}
+
+	filter, ok := parseFilter(c)
+	if !ok {
+		return
+	}
+	filter.UserID = userClaims.UserID
+
+	service.respondWithEvents(c, filter)
+}
+
+// ListEvents возвращает события всех пользователей (для администратора).
+// Дополнительно к фильтрам ListOwnEvents поддерживаются user_id и ip.
+func (service *SecurityService) ListEvents(c *gin.Context) {
+	filter, ok := parseFilter(c)
+	if !ok {
+		return
+	}
+
+	if param := c.Query("user_id"); param != "" {
+		userID, err := strconv.Atoi(param)
+		if err != nil || userID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		filter.UserID = userID
+	}
+	filter.IP = c.Query("ip")
+
+	service.respondWithEvents(c, filter)
+}
+
+func (service *SecurityService) respondWithEvents(c *gin.Context, filter models.SecurityEventFilter) {
+	events, err := service.repo.GetSecurityEvents(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get security events"})
+		service.log.Error(err.Error())
+		return
+	}
+
+	// Курсор следующей страницы: события старше последнего в выдаче
+	var nextBefore *int64
+	if len(events) == filter.Limit {
+		nextBefore = &events[len(events)-1].ID
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":      events,
+		"next_before": nextBefore,
+	})
+}
+
+// parseFilter разбирает общие параметры запроса. При ошибке ответ уже отправлен клиенту.
+func parseFilter(c *gin.Context) (models.SecurityEventFilter, bool) {
+	filter := models.SecurityEventFilter{
+		Type:  c.Query("type"),
+		Limit: defaultLimit,
+	}
+
+	if param := c.Query("limit"); param != "" {
+		limit, err := strconv.Atoi(param)
+		if err != nil || limit <= 0 || limit > maxLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Limit must be between 1 and " + strconv.Itoa(maxLimit)})
+			return filter, false
+		}
+		filter.Limit = limit
+	}
+
+	if param := c.Query("before"); param != "" {
+		beforeID, err := strconv.ParseInt(param, 10, 64)
+		if err != nil || beforeID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return filter, false
+		}
+		filter.BeforeID = beforeID
+	}
+
+	for name, target := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		param := c.Query(name)
+		if param == "" {
+			continue
+		}
+
+		value, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " time, expected RFC 3339"})
+			return filter, false
+		}
+		*target = &value
+	}
+
+	return filter, true
+}
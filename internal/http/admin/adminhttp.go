@@ -0,0 +1,23 @@
+package adminhttp
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Admin определяет интерфейс администрирования пользователей
+type Admin interface {
+	GetUser(c *gin.Context)      // Получение учетной записи пользователя
+	SetUserRoles(c *gin.Context) // Изменение ролей пользователя
+}
+
+// Register регистрирует маршруты администратора. guards - проверки ролей и областей доступа
+// (RequireRole/RequireScope), выполняемые после аутентификации.
+func Register(router *gin.Engine, adminService Admin, authMiddleware gin.HandlerFunc, guards ...gin.HandlerFunc) {
+	adminGroup := router.Group("/admin")
+	adminGroup.Use(authMiddleware)
+	adminGroup.Use(guards...)
+	{
+		adminGroup.GET("/users/:id", adminService.GetUser)
+		adminGroup.PUT("/users/:id/roles", adminService.SetUserRoles)
+	}
+}
@@ -0,0 +1,33 @@
+package apikeyshttp
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeys определяет интерфейс управления API-ключами
+type APIKeys interface {
+	CreateAPIKey(c *gin.Context) // Выпуск ключа
+	ListAPIKeys(c *gin.Context)  // Список ключей
+	RevokeAPIKey(c *gin.Context) // Отзыв ключа
+}
+
+// Register регистрирует маршруты API-ключей текущего пользователя и, с проверками
+// adminGuards, маршруты администратора для ключей любого пользователя
+func Register(router *gin.Engine, apiKeysService APIKeys, authMiddleware gin.HandlerFunc, adminGuards ...gin.HandlerFunc) {
+	profileKeysGroup := router.Group("/profile/api-keys")
+	profileKeysGroup.Use(authMiddleware)
+	{
+		profileKeysGroup.GET("", apiKeysService.ListAPIKeys)
+		profileKeysGroup.POST("", apiKeysService.CreateAPIKey)
+		profileKeysGroup.DELETE("/:keyID", apiKeysService.RevokeAPIKey)
+	}
+
+	adminKeysGroup := router.Group("/admin/users/:id/api-keys")
+	adminKeysGroup.Use(authMiddleware)
+	adminKeysGroup.Use(adminGuards...)
+	{
+		adminKeysGroup.GET("", apiKeysService.ListAPIKeys)
+		adminKeysGroup.POST("", apiKeysService.CreateAPIKey)
+		adminKeysGroup.DELETE("/:keyID", apiKeysService.RevokeAPIKey)
+	}
+}
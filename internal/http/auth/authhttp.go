@@ -7,9 +7,82 @@ import (
 type Auth interface {
 	Login(c *gin.Context)
 	Register(c *gin.Context)
+	Refresh(c *gin.Context)
+	Logout(c *gin.Context)
+	LogoutAll(c *gin.Context)
+	VerifyEmail(c *gin.Context)
+	ResendVerification(c *gin.Context)
+	ForgotPassword(c *gin.Context)
+	ResetPassword(c *gin.Context)
+	Login2FA(c *gin.Context)
+	EnrollTOTP(c *gin.Context)
+	ConfirmTOTP(c *gin.Context)
+	DisableTOTP(c *gin.Context)
+	OIDCLogin(c *gin.Context)
+	OIDCCallback(c *gin.Context)
+	ListSessions(c *gin.Context)
+	RevokeSession(c *gin.Context)
+	ChangePassword(c *gin.Context)
+	RestoreAccount(c *gin.Context)
+	RequestMagicLink(c *gin.Context)
+	VerifyMagicLink(c *gin.Context)
 }
 
-func Register(router *gin.Engine, authService Auth) {
+func Register(router *gin.Engine, authService Auth, authMiddleware gin.HandlerFunc) {
 	router.POST("/login", authService.Login)
+	router.POST("/login/2fa", authService.Login2FA)
+
+	// Вход без пароля по ссылке из письма: GET - переход по ссылке, POST - запрос с фронтенда
+	router.POST("/login/magic", authService.RequestMagicLink)
+	router.GET("/login/magic/verify", authService.VerifyMagicLink)
+	router.POST("/login/magic/verify", authService.VerifyMagicLink)
+
+	// Вход через OpenID Connect провайдеров
+	router.GET("/oauth/:provider/login", authService.OIDCLogin)
+	router.GET("/oauth/:provider/callback", authService.OIDCCallback)
 	router.POST("/register", authService.Register)
+	router.POST("/token/refresh", authService.Refresh)
+
+	// Подтверждение email: GET - переход по ссылке из письма, POST - запрос с фронтенда
+	router.GET("/verify-email", authService.VerifyEmail)
+	router.POST("/verify-email", authService.VerifyEmail)
+	router.POST("/verify-email/resend", authService.ResendVerification)
+
+	router.POST("/password/forgot", authService.ForgotPassword)
+	router.POST("/password/reset", authService.ResetPassword)
+
+	// Отмена удаления учетной записи (вход в нее заблокирован)
+	router.POST("/account/restore", authService.RestoreAccount)
+
+	// Выход требует действующего access-токена
+	logoutGroup := router.Group("/logout")
+	logoutGroup.Use(authMiddleware)
+	{
+		logoutGroup.POST("", authService.Logout)
+		logoutGroup.POST("/all", authService.LogoutAll)
+	}
+
+	// Управление двухфакторной аутентификацией текущего пользователя
+	twoFactorGroup := router.Group("/profile/2fa")
+	twoFactorGroup.Use(authMiddleware)
+	{
+		twoFactorGroup.POST("/enroll", authService.EnrollTOTP)
+		twoFactorGroup.POST("/confirm", authService.ConfirmTOTP)
+		twoFactorGroup.DELETE("", authService.DisableTOTP)
+	}
+
+	// Смена пароля текущего пользователя
+	passwordGroup := router.Group("/profile/password")
+	passwordGroup.Use(authMiddleware)
+	{
+		passwordGroup.PUT("", authService.ChangePassword)
+	}
+
+	// Устройства, на которых выполнен вход
+	sessionsGroup := router.Group("/profile/sessions")
+	sessionsGroup.Use(authMiddleware)
+	{
+		sessionsGroup.GET("", authService.ListSessions)
+		sessionsGroup.DELETE("/:id", authService.RevokeSession)
+	}
 }
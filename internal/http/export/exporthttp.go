@@ -0,0 +1,23 @@
+package exporthttp
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Export определяет интерфейс выгрузки персональных данных
+type Export interface {
+	RequestExport(c *gin.Context)  // Запрос выгрузки
+	GetExport(c *gin.Context)      // Статус выгрузки
+	DownloadExport(c *gin.Context) // Скачивание архива
+}
+
+// Register регистрирует маршруты выгрузки персональных данных
+func Register(router *gin.Engine, exportService Export, authMiddleware gin.HandlerFunc) {
+	exportGroup := router.Group("/profile/export")
+	exportGroup.Use(authMiddleware)
+	{
+		exportGroup.POST("", exportService.RequestExport)
+		exportGroup.GET("/:id", exportService.GetExport)
+		exportGroup.GET("/:id/download", exportService.DownloadExport)
+	}
+}
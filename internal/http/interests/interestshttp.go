@@ -0,0 +1,45 @@
+package interestshttp
+
+import (
+	"passion-pals-backend/internal/utils/middleware"
+	"passion-pals-backend/internal/utils/rbac"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Interests определяет интерфейс каталога интересов
+type Interests interface {
+	GetCatalog(c *gin.Context)          // Каталог интересов
+	GetProfileInterests(c *gin.Context) // Интересы анкеты текущего пользователя
+	SetProfileInterests(c *gin.Context) // Замена интересов анкеты
+	CreateCategory(c *gin.Context)      // Добавление категории
+	CreateInterest(c *gin.Context)      // Добавление интереса
+	UpdateInterest(c *gin.Context)      // Изменение интереса
+	DeleteInterest(c *gin.Context)      // Удаление интереса
+}
+
+// Register регистрирует маршруты каталога и интересов анкеты и, с проверками
+// adminGuards, маршруты администратора для управления каталогом
+func Register(router *gin.Engine, interestsService Interests, authMiddleware gin.HandlerFunc, adminGuards ...gin.HandlerFunc) {
+	canRead := middleware.RequireScope(rbac.ScopeProfileRead)
+	canWrite := middleware.RequireScope(rbac.ScopeProfileWrite)
+
+	router.GET("/interests", authMiddleware, canRead, interestsService.GetCatalog)
+
+	profileInterestsGroup := router.Group("/profile/interests")
+	profileInterestsGroup.Use(authMiddleware)
+	{
+		profileInterestsGroup.GET("", canRead, interestsService.GetProfileInterests)
+		profileInterestsGroup.PUT("", canWrite, interestsService.SetProfileInterests)
+	}
+
+	adminInterestsGroup := router.Group("/admin")
+	adminInterestsGroup.Use(authMiddleware)
+	adminInterestsGroup.Use(adminGuards...)
+	{
+		adminInterestsGroup.POST("/interest-categories", interestsService.CreateCategory)
+		adminInterestsGroup.POST("/interests", interestsService.CreateInterest)
+		adminInterestsGroup.PATCH("/interests/:id", interestsService.UpdateInterest)
+		adminInterestsGroup.DELETE("/interests/:id", interestsService.DeleteInterest)
+	}
+}
@@ -1,8 +1,6 @@
 package profilehttp
 
 import (
-	"passion-pals-backend/internal/utils/middleware"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,10 +11,10 @@ type Notification interface {
 }
 
 // Register регистрирует маршруты для работы с профилями
-func Register(router *gin.Engine, notificationService Notification) {
+func Register(router *gin.Engine, notificationService Notification, authMiddleware gin.HandlerFunc) {
 	// Группа маршрутов для работы с уведомлениями текущего пользователя
 	profileGroup := router.Group("/profile")
-	profileGroup.Use(middleware.AuthMiddleware())
+	profileGroup.Use(authMiddleware)
 	{
 		profileGroup.GET("/notifications", notificationService.GetNotifications)    // Получить уведомления
 		profileGroup.PUT("/notifications/:id/read", notificationService.MarkAsRead) // Отметить как прочитанное
@@ -0,0 +1,37 @@
+package photoshttp
+
+import (
+	"passion-pals-backend/internal/utils/middleware"
+	"passion-pals-backend/internal/utils/rbac"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Photos определяет интерфейс галереи фотографий анкеты
+type Photos interface {
+	GetPhotos(c *gin.Context)        // Галерея текущего пользователя
+	GetProfilePhotos(c *gin.Context) // Галерея анкеты по ID
+	UploadPhoto(c *gin.Context)      // Загрузка фотографии
+	ReorderPhotos(c *gin.Context)    // Изменение порядка фотографий
+	SetPrimaryPhoto(c *gin.Context)  // Выбор основной фотографии
+	DeletePhoto(c *gin.Context)      // Удаление фотографии
+}
+
+// Register регистрирует маршруты галереи фотографий
+func Register(router *gin.Engine, photosService Photos, authMiddleware gin.HandlerFunc) {
+	canRead := middleware.RequireScope(rbac.ScopeProfileRead)
+	canWrite := middleware.RequireScope(rbac.ScopeProfileWrite)
+
+	photosGroup := router.Group("/profile/photos")
+	photosGroup.Use(authMiddleware)
+	{
+		photosGroup.GET("", canRead, photosService.GetPhotos)
+		// POST /profile/photos - multipart/form-data с файлом в поле photo
+		photosGroup.POST("", canWrite, photosService.UploadPhoto)
+		photosGroup.PUT("/order", canWrite, photosService.ReorderPhotos)
+		photosGroup.PUT("/:id/primary", canWrite, photosService.SetPrimaryPhoto)
+		photosGroup.DELETE("/:id", canWrite, photosService.DeletePhoto)
+	}
+
+	router.GET("/profiles/:id/photos", authMiddleware, canRead, photosService.GetProfilePhotos)
+}
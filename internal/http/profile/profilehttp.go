@@ -2,42 +2,60 @@ package profilehttp
 
 import (
 	"passion-pals-backend/internal/utils/middleware"
+	"passion-pals-backend/internal/utils/rbac"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Profile определяет интерфейс для работы с профилями
 type Profile interface {
-	GetUserProfile(c *gin.Context)    // Получение профиля текущего пользователя
-	GetProfiles(c *gin.Context)       // Получение списка всех профилей
-	GetProfileByID(c *gin.Context)    // Получение профиля по ID
-	EditUserProfile(c *gin.Context)   // Редактирование профиля текущего пользователя
-	DeleteUserProfile(c *gin.Context) // Редактирование профиля текущего пользователя
+	GetUserProfile(c *gin.Context)         // Получение профиля текущего пользователя
+	GetProfiles(c *gin.Context)            // Получение списка всех профилей
+	GetRecommendedProfiles(c *gin.Context) // Рекомендованные анкеты
+	GetProfileByID(c *gin.Context)         // Получение профиля по ID
+	EditUserProfile(c *gin.Context)        // Редактирование профиля текущего пользователя
+	DeleteUserProfile(c *gin.Context)      // Редактирование профиля текущего пользователя
+	GetPreferences(c *gin.Context)         // Предпочтения подбора текущего пользователя
+	PutPreferences(c *gin.Context)         // Замена предпочтений подбора
 }
 
-// Register регистрирует маршруты для работы с профилями
-func Register(router *gin.Engine, profileService Profile) {
+// Register регистрирует маршруты для работы с профилями.
+// Удаление учетной записи доступно только интерактивной сессии (sessionMiddleware), не API-ключам.
+func Register(router *gin.Engine, profileService Profile, authMiddleware, sessionMiddleware gin.HandlerFunc) {
+	// Области доступа важны для API-ключей: у JWT пользователя есть обе
+	canRead := middleware.RequireScope(rbac.ScopeProfileRead)
+	canWrite := middleware.RequireScope(rbac.ScopeProfileWrite)
+
 	// Группа маршрутов для работы с профилем текущего пользователя
 	profileGroup := router.Group("/profile")
-	profileGroup.Use(middleware.AuthMiddleware()) // Применяем middleware для аутентификации
+	profileGroup.Use(authMiddleware) // Применяем middleware для аутентификации
 	{
 		// GET /profile - получение профиля текущего пользователя
-		profileGroup.GET("", profileService.GetUserProfile)
+		profileGroup.GET("", canRead, profileService.GetUserProfile)
 
-		// PUT /profile - редактирование профиля текущего пользователя
-		profileGroup.PUT("", profileService.EditUserProfile)
+		// PUT /profile - замена анкеты, PATCH /profile - изменение отдельных полей (оба требуют If-Match)
+		profileGroup.PUT("", canWrite, profileService.EditUserProfile)
+		profileGroup.PATCH("", canWrite, profileService.EditUserProfile)
 
-		profileGroup.DELETE("", profileService.DeleteUserProfile)
+		// GET/PUT /profile/preferences - предпочтения подбора для ленты и рекомендаций
+		profileGroup.GET("/preferences", canRead, profileService.GetPreferences)
+		profileGroup.PUT("/preferences", canWrite, profileService.PutPreferences)
 	}
 
+	// DELETE /profile - удаление учетной записи с подтверждением пароля
+	router.DELETE("/profile", sessionMiddleware, profileService.DeleteUserProfile)
+
 	// Группа маршрутов для работы с профилями других пользователей
 	profilesGroup := router.Group("/profiles")
-	profilesGroup.Use(middleware.AuthMiddleware()) // Применяем middleware для аутентификации
+	profilesGroup.Use(authMiddleware) // Применяем middleware для аутентификации
 	{
 		// GET /profiles - получение списка всех профилей
-		profilesGroup.GET("", profileService.GetProfiles)
+		profilesGroup.GET("", canRead, profileService.GetProfiles)
+
+		// GET /profiles/recommended - анкеты, ранжированные для текущего пользователя
+		profilesGroup.GET("/recommended", canRead, profileService.GetRecommendedProfiles)
 
 		// GET /profiles/:id - получение профиля по ID
-		profilesGroup.GET("/:id", profileService.GetProfileByID)
+		profilesGroup.GET("/:id", canRead, profileService.GetProfileByID)
 	}
 }
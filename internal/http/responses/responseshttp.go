@@ -1,8 +1,6 @@
 package profilehttp
 
 import (
-	"passion-pals-backend/internal/utils/middleware"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -16,10 +14,10 @@ type Response interface {
 }
 
 // Register регистрирует маршруты для работы с профилями
-func Register(router *gin.Engine, responseService Response) {
+func Register(router *gin.Engine, responseService Response, authMiddleware gin.HandlerFunc) {
 	// Группа маршрутов для работы с откликами текущего пользователя
 	profileGroup := router.Group("/profile")
-	profileGroup.Use(middleware.AuthMiddleware()) // Применяем middleware для аутентификации
+	profileGroup.Use(authMiddleware) // Применяем middleware для аутентификации
 	{
 		// GET /profile/responses - получение откликов текущего пользователя
 		profileGroup.GET("/responses", responseService.GetResponses)
@@ -28,7 +26,7 @@ func Register(router *gin.Engine, responseService Response) {
 	}
 
 	profilesGroup := router.Group("/profiles/:id")
-	profilesGroup.Use(middleware.AuthMiddleware()) // Применяем middleware для аутентификации
+	profilesGroup.Use(authMiddleware) // Применяем middleware для аутентификации
 	{
 		// POST /profiles/:id - добавление нового отклика на указанный :id
 		profilesGroup.POST("", responseService.PostResponse)
@@ -0,0 +1,24 @@
+package securityhttp
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Security определяет интерфейс журнала событий безопасности
+type Security interface {
+	ListOwnEvents(c *gin.Context) // События текущего пользователя
+	ListEvents(c *gin.Context)    // События всех пользователей
+}
+
+// Register регистрирует маршрут журнала текущего пользователя и, с проверками
+// adminGuards, маршрут администратора для событий всех пользователей
+func Register(router *gin.Engine, securityService Security, authMiddleware gin.HandlerFunc, adminGuards ...gin.HandlerFunc) {
+	router.GET("/profile/security-events", authMiddleware, securityService.ListOwnEvents)
+
+	adminEventsGroup := router.Group("/admin/security-events")
+	adminEventsGroup.Use(authMiddleware)
+	adminEventsGroup.Use(adminGuards...)
+	{
+		adminEventsGroup.GET("", securityService.ListEvents)
+	}
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// APIKey API-ключ пользователя для неинтерактивного доступа
+type APIKey struct {
+	ID         int64      `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  int        `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP *string    `json:"last_used_ip"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// Roles текущие роли владельца ключа (заполняется при аутентификации)
+	Roles []string `json:"-"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Статусы выгрузки персональных данных
+const (
+	ExportPending    = "pending"
+	ExportProcessing = "processing"
+	ExportReady      = "ready"
+	ExportFailed     = "failed"
+)
+
+// DataExport выгрузка персональных данных пользователя
+type DataExport struct {
+	ID          string     `json:"id"`
+	UserID      int        `json:"-"`
+	Status      string     `json:"status"`
+	FilePath    string     `json:"-"`
+	SizeBytes   *int64     `json:"size_bytes,omitempty"`
+	Error       *string    `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// RelationshipIntents варианты цели знакомства
+var RelationshipIntents = []string{"friendship", "dating", "relationship"}
+
+// DiscoveryPreferences кого пользователь хочет видеть в ленте. nil и пустые значения - без ограничения.
+type DiscoveryPreferences struct {
+	MinAge        *int      `json:"min_age"`
+	MaxAge        *int      `json:"max_age"`
+	Genders       []string  `json:"genders"`
+	MaxDistanceKm *int      `json:"max_distance_km"` // Действует, только если указаны свои координаты
+	Intent        string    `json:"intent"`          // Цель знакомства; совпадение требуется, если указана у обоих
+	UpdatedAt     time.Time `json:"updated_at" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// MutualMatch данные смотрящего для взаимного подбора: кандидат должен подходить под его
+// предпочтения, а он сам - под предпочтения кандидата
+type MutualMatch struct {
+	Preferences DiscoveryPreferences
+	Age         int    // Возраст смотрящего, 0 - неизвестен
+	Gender      string // Пол смотрящего
+}
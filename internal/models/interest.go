@@ -0,0 +1,25 @@
+package model
+
+// InterestCategory категория каталога интересов
+type InterestCategory struct {
+	ID        int        `json:"id"`
+	Slug      string     `json:"slug"`
+	Name      string     `json:"name"`
+	Interests []Interest `json:"interests,omitempty"`
+}
+
+// Interest интерес из каталога. Синонимы помогают найти интерес при поиске и выборе.
+type Interest struct {
+	ID         int      `json:"id"`
+	Slug       string   `json:"slug"`
+	Name       string   `json:"name"`
+	CategoryID int      `json:"category_id"`
+	Synonyms   []string `json:"synonyms"`
+}
+
+// InterestUpdate изменяемые поля интереса. nil - поле не меняется.
+type InterestUpdate struct {
+	Name       *string
+	CategoryID *int
+	Synonyms   []string // nil - синонимы не меняются, пустой список - удалить все
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// OIDCAuthRequest незавершенная попытка входа через OpenID Connect провайдера
+type OIDCAuthRequest struct {
+	State        string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+// ExternalIdentity данные пользователя, полученные от провайдера
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+}
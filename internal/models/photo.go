@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// ProfilePhoto фотография из галереи анкеты
+type ProfilePhoto struct {
+	ID        int            `json:"id"`
+	Position  int            `json:"position"` // Порядок в галерее, с 0
+	IsPrimary bool           `json:"is_primary"`
+	Variants  []PhotoVariant `json:"variants"`
+	CreatedAt time.Time      `json:"created_at" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// PhotoVariant копия фотографии, уменьшенная до Size пикселей по большей стороне
+type PhotoVariant struct {
+	Size   int    `json:"size"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
+	Key    string `json:"-"` // Ключ файла в хранилище
+}
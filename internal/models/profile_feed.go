@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Варианты сортировки ленты анкет
+const (
+	FeedSortRecent  = "recent"   // Недавно обновленные
+	FeedSortNewest  = "newest"   // Недавно созданные
+	FeedSortAgeAsc  = "age_asc"  // Сначала младшие
+	FeedSortAgeDesc = "age_desc" // Сначала старшие
+)
+
+// ProfileFilter параметры выборки ленты анкет
+type ProfileFilter struct {
+	ViewerID    int // Своя анкета в ленту не попадает
+	MinAge      int // 0 - без ограничения
+	MaxAge      int // 0 - без ограничения
+	Gender      string
+	LookingFor  string
+	ActiveSince *time.Time // Анкеты, обновленные не раньше
+	InterestIDs []int      // Интересы из каталога, без повторов
+	// MatchAllInterests - анкета должна содержать все InterestIDs, иначе хотя бы один
+	MatchAllInterests bool
+	// Origin координаты смотрящего: для анкет вычисляется расстояние до него
+	Origin *GeoPoint
+	// WithinKm только анкеты не дальше этого расстояния от Origin в целых километрах, 0 - без ограничения
+	WithinKm int
+	// Mutual предпочтения подбора смотрящего, nil - без взаимного подбора
+	Mutual *MutualMatch
+	Sort   string
+	After  *ProfileCursor // Продолжение выборки после этой позиции
+	Limit  int
+}
+
+// ProfileCursor позиция в ленте: значение ключа сортировки и id последней выданной анкеты
+type ProfileCursor struct {
+	Sort string    `json:"s"`
+	Key  time.Time `json:"k"`
+	ID   int       `json:"id"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// RevokedToken отозванный access-токен
+type RevokedToken struct {
+	JTI       string
+	UserID    int
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
+
+// UserTokenRevocation отзыв всех токенов пользователя, выпущенных до RevokedBefore
+type UserTokenRevocation struct {
+	UserID        int
+	RevokedBefore time.Time
+	ExpiresAt     time.Time
+	RevokedAt     time.Time
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// SecurityEvent запись журнала событий безопасности
+type SecurityEvent struct {
+	ID        int64          `json:"id"`
+	UserID    *int           `json:"user_id"`
+	ActorID   *int           `json:"actor_id,omitempty"` // Администратор, выполнивший действие над учетной записью
+	Type      string         `json:"type"`
+	IP        string         `json:"ip"`
+	UserAgent string         `json:"user_agent"`
+	RequestID string         `json:"request_id"`
+	Details   map[string]any `json:"details"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// SecurityEventFilter условия выборки журнала. Пустые поля не ограничивают выборку.
+type SecurityEventFilter struct {
+	UserID   int
+	Type     string
+	IP       string
+	Since    *time.Time
+	Until    *time.Time
+	BeforeID int64 // Курсор: события с id меньше BeforeID
+	Limit    int
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Session сессия пользователя на одном устройстве
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int       `json:"-"`
+	CurrentJTI string    `json:"-"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
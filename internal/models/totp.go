@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// TOTPState состояние двухфакторной аутентификации пользователя
+type TOTPState struct {
+	Secret    string
+	EnabledAt *time.Time
+	LastStep  *int64
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// User учетная запись пользователя
+type User struct {
+	ID              int
+	Username        string
+	Email           string
+	PasswordHash    string
+	EmailVerifiedAt *time.Time
+	TOTPEnabled     bool
+	Roles           []string
+	// DeletedAt когда пользователь запросил удаление учетной записи (nil - не запрашивал)
+	DeletedAt *time.Time
+}
@@ -1,14 +1,119 @@
 package model
 
-import "time"
+import (
+	"math"
+	"slices"
+	"time"
+)
 
 type UserProfile struct {
-	Username   string    `json:"username"`
-	Age        int       `json:"age"`
-	AvatarUrl  string    `json:"avatar_url"`
-	AboutMe    string    `json:"about_me"`
-	Gender     string    `json:"gender"`
-	LookingFor string    `json:"looking_for"`
-	CreatedAt  time.Time `json:"created_at" time_format:"2006-01-02T15:04:05Z07:00"`
-	UpdatedAt  time.Time `json:"updated_at" time_format:"2006-01-02T15:04:05Z07:00"`
+	ID           int       `json:"id"`
+	UserID       int       `json:"-"`
+	Username     string    `json:"username"`
+	Age          int       `json:"age"`
+	AvatarUrl    string    `json:"avatar_url"`
+	AboutMe      string    `json:"about_me"`
+	Gender       string    `json:"gender"`
+	LookingFor   string    `json:"looking_for"`
+	Interests    []string  `json:"interests"`     // slug интересов из каталога
+	HiddenFields []string  `json:"hidden_fields"` // Поля, скрытые владельцем от других пользователей
+	City         string    `json:"city"`
+	Latitude     *float64  `json:"latitude"`              // Точные координаты видит только владелец
+	Longitude    *float64  `json:"longitude"`             // Точные координаты видит только владелец
+	DistanceKm   *int      `json:"distance_km,omitempty"` // Округленное расстояние до смотрящего
+	CreatedAt    time.Time `json:"created_at" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedAt    time.Time `json:"updated_at" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// HideableProfileFields поля анкеты, которые владелец может скрыть настройками приватности
+// location скрывает город и расстояние, photos - галерею фотографий
+var HideableProfileFields = []string{"age", "avatar_url", "about_me", "gender", "looking_for", "interests", "location", "photos"}
+
+// ViewFor возвращает анкету в том виде, в каком ее видит пользователь viewerID:
+// владельцу - целиком, остальным - без скрытых полей, настроек приватности и координат
+func (p *UserProfile) ViewFor(viewerID int) any {
+	if viewerID == p.UserID {
+		return p
+	}
+
+	view := map[string]any{
+		"id":          p.ID,
+		"username":    p.Username,
+		"age":         p.Age,
+		"avatar_url":  p.AvatarUrl,
+		"about_me":    p.AboutMe,
+		"gender":      p.Gender,
+		"looking_for": p.LookingFor,
+		"interests":   p.Interests,
+		"city":        p.City,
+		"created_at":  p.CreatedAt,
+		"updated_at":  p.UpdatedAt,
+	}
+	if p.DistanceKm != nil {
+		view["distance_km"] = *p.DistanceKm
+	}
+
+	for _, field := range p.HiddenFields {
+		if slices.Contains(HideableProfileFields, field) {
+			delete(view, field)
+		}
+	}
+	if p.IsHidden("location") {
+		delete(view, "city")
+		delete(view, "distance_km")
+	}
+
+	return view
+}
+
+// ProfileUpdate изменяемые поля анкеты. nil - поле не меняется.
+type ProfileUpdate struct {
+	AboutMe      *string
+	AvatarURL    *string
+	Gender       *string
+	LookingFor   *string
+	HiddenFields []string
+	Location     *ProfileLocation
+}
+
+// ProfileLocation местоположение анкеты. Заменяется целиком: nil-координаты удаляют их.
+type ProfileLocation struct {
+	Latitude  *float64
+	Longitude *float64
+	City      string
+}
+
+// GridStep шаг сетки в градусах (около 1 км по широте), к которой перед расчетом расстояния
+// привязываются координаты анкет и смотрящего: иначе, меняя свое местоположение и радиус
+// поиска, положение другого пользователя можно вычислить трилатерацией
+const GridStep = 0.01
+
+// GeoPoint координаты в градусах
+type GeoPoint struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Snapped возвращает точку, привязанную к сетке GridStep
+func (p GeoPoint) Snapped() GeoPoint {
+	return GeoPoint{Latitude: Snap(p.Latitude), Longitude: Snap(p.Longitude)}
+}
+
+// Snap привязывает координату к сетке GridStep
+func Snap(degrees float64) float64 {
+	return math.Round(degrees/GridStep) * GridStep
+}
+
+// Location возвращает координаты анкеты или nil, если они не указаны
+func (p *UserProfile) Location() *GeoPoint {
+	if p.Latitude == nil || p.Longitude == nil {
+		return nil
+	}
+
+	return &GeoPoint{Latitude: *p.Latitude, Longitude: *p.Longitude}
+}
+
+// IsHidden сообщает, скрыл ли владелец поле от других пользователей
+func (p *UserProfile) IsHidden(field string) bool {
+	return slices.Contains(p.HiddenFields, field)
 }
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	models "passion-pals-backend/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// CreateAPIKey сохраняет новый API-ключ и возвращает его id
+func (r *Repository) CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) (int64, error) {
+	var id int64
+
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, created_by, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id`,
+		key.UserID, key.Name, key.Prefix, keyHash, key.Scopes, key.CreatedBy, key.CreatedAt, key.ExpiresAt).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetUserAPIKeys возвращает неотозванные API-ключи пользователя, включая истекшие
+func (r *Repository) GetUserAPIKeys(ctx context.Context, userID int) ([]models.APIKey, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT id, user_id, name, prefix, scopes, created_by, created_at, expires_at, last_used_at, last_used_ip, revoked_at
+        FROM api_keys
+        WHERE user_id = $1 AND revoked_at IS NULL
+        ORDER BY created_at DESC`,
+		userID)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var key models.APIKey
+		if err := rows.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Scopes, &key.CreatedBy,
+			&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.LastUsedIP, &key.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// FindAPIKeyByHash ищет действующий (не отозванный и не истекший) ключ по хэшу вместе с ролями владельца
+func (r *Repository) FindAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+
+	err := r.db.QueryRow(ctx,
+		`SELECT k.id, k.user_id, k.name, k.prefix, k.scopes, k.created_by, k.created_at, k.expires_at,
+            k.last_used_at, k.last_used_ip, u.roles
+        FROM api_keys k
+        JOIN users u ON u.id = k.user_id
+        WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND k.expires_at > $2
+            AND u.deleted_at IS NULL`,
+		keyHash, time.Now()).Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Scopes, &key.CreatedBy,
+		&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.LastUsedIP, &key.Roles)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to find api key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// TouchAPIKey запоминает время и адрес последнего использования ключа
+func (r *Repository) TouchAPIKey(ctx context.Context, keyID int64, usedAt time.Time, ip string) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE api_keys SET last_used_at = $1, last_used_ip = $2 WHERE id = $3",
+		usedAt, ip, keyID)
+
+	if err != nil {
+		return fmt.Errorf("failed to touch api key: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAPIKey отзывает ключ keyID пользователя userID
+func (r *Repository) RevokeAPIKey(ctx context.Context, userID int, keyID int64) error {
+	tag, err := r.db.Exec(ctx,
+		"UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL",
+		time.Now(), keyID, userID)
+
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
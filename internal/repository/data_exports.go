@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	models "passion-pals-backend/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrDataExportNotFound = errors.New("data export not found")
+
+const dataExportColumns = "id, user_id, status, COALESCE(file_path, ''), size_bytes, error, created_at, completed_at, expires_at"
+
+func scanDataExport(row pgx.Row) (*models.DataExport, error) {
+	var export models.DataExport
+
+	err := row.Scan(&export.ID, &export.UserID, &export.Status, &export.FilePath, &export.SizeBytes,
+		&export.Error, &export.CreatedAt, &export.CompletedAt, &export.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// CreateDataExport ставит выгрузку в очередь. Если у пользователя уже есть
+// незавершенная выгрузка, новая не создается и возвращается существующая.
+func (r *Repository) CreateDataExport(ctx context.Context, id string, userID int) (*models.DataExport, bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокируем пользователя, чтобы параллельные запросы не создали две выгрузки
+	if _, err := tx.Exec(ctx, "SELECT 1 FROM users WHERE id = $1 FOR UPDATE", userID); err != nil {
+		return nil, false, fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	existing, err := scanDataExport(tx.QueryRow(ctx,
+		`SELECT `+dataExportColumns+` FROM data_exports
+        WHERE user_id = $1 AND status IN ($2, $3)
+        ORDER BY created_at DESC
+        LIMIT 1`,
+		userID, models.ExportPending, models.ExportProcessing))
+	if err == nil {
+		return existing, false, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, fmt.Errorf("failed to find data export: %w", err)
+	}
+
+	export, err := scanDataExport(tx.QueryRow(ctx,
+		`INSERT INTO data_exports (id, user_id, status, created_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING `+dataExportColumns,
+		id, userID, models.ExportPending, time.Now()))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create data export: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return export, true, nil
+}
+
+// GetDataExport возвращает выгрузку id пользователя userID
+func (r *Repository) GetDataExport(ctx context.Context, userID int, id string) (*models.DataExport, error) {
+	export, err := scanDataExport(r.db.QueryRow(ctx,
+		`SELECT `+dataExportColumns+` FROM data_exports WHERE id = $1 AND user_id = $2`,
+		id, userID))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDataExportNotFound
+		}
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+
+	return export, nil
+}
+
+// ClaimDataExport берет в работу самую старую ожидающую выгрузку. Выгрузки,
+// зависшие в processing дольше staleAfter (например, после падения инстанса),
+// берутся повторно. Возвращает ErrDataExportNotFound, если очередь пуста.
+func (r *Repository) ClaimDataExport(ctx context.Context, staleAfter time.Duration) (*models.DataExport, error) {
+	now := time.Now()
+
+	export, err := scanDataExport(r.db.QueryRow(ctx,
+		`UPDATE data_exports SET status = $1, started_at = $2
+        WHERE id = (
+            SELECT id FROM data_exports
+            WHERE status = $3 OR (status = $1 AND started_at < $4)
+            ORDER BY created_at
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING `+dataExportColumns,
+		models.ExportProcessing, now, models.ExportPending, now.Add(-staleAfter)))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDataExportNotFound
+		}
+		return nil, fmt.Errorf("failed to claim data export: %w", err)
+	}
+
+	return export, nil
+}
+
+// CompleteDataExport отмечает выгрузку готовой
+func (r *Repository) CompleteDataExport(ctx context.Context, id, filePath string, sizeBytes int64, expiresAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE data_exports
+        SET status = $1, file_path = $2, size_bytes = $3, completed_at = $4, expires_at = $5
+        WHERE id = $6`,
+		models.ExportReady, filePath, sizeBytes, time.Now(), expiresAt, id)
+
+	if err != nil {
+		return fmt.Errorf("failed to complete data export: %w", err)
+	}
+
+	return nil
+}
+
+// FailDataExport отмечает выгрузку неудавшейся. Запись хранится до expiresAt, чтобы пользователь увидел статус.
+func (r *Repository) FailDataExport(ctx context.Context, id, reason string, expiresAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE data_exports SET status = $1, error = $2, completed_at = $3, expires_at = $4 WHERE id = $5",
+		models.ExportFailed, reason, time.Now(), expiresAt, id)
+
+	if err != nil {
+		return fmt.Errorf("failed to fail data export: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredDataExports удаляет записи выгрузок, истекших до before,
+// и возвращает пути их архивов для удаления с диска
+func (r *Repository) DeleteExpiredDataExports(ctx context.Context, before time.Time) ([]string, error) {
+	rows, err := r.db.Query(ctx,
+		`DELETE FROM data_exports
+        WHERE expires_at < $1
+        RETURNING COALESCE(file_path, '')`,
+		before)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired data exports: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan data export: %w", err)
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, rows.Err()
+}
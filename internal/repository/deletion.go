@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrUserNotDeleted     = errors.New("user is not scheduled for deletion")
+	ErrUserAlreadyDeleted = errors.New("user is already scheduled for deletion")
+)
+
+// purgeBatchSize сколько учетных записей удаляется за одну транзакцию
+const purgeBatchSize = 100
+
+// MarkUserDeleted помечает учетную запись как ожидающую удаления и отзывает
+// все ее сессии, refresh-токены и API-ключи. Если удаление уже запрошено,
+// возвращает ErrUserAlreadyDeleted.
+func (r *Repository) MarkUserDeleted(ctx context.Context, userID int, deletedAt time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		"UPDATE users SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL",
+		deletedAt, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark user deleted: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)", userID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check user: %w", err)
+		}
+		if exists {
+			return ErrUserAlreadyDeleted
+		}
+		return ErrUserNotFound
+	}
+
+	for _, query := range []string{
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+		"UPDATE sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+		"UPDATE api_keys SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+	} {
+		if _, err := tx.Exec(ctx, query, deletedAt, userID); err != nil {
+			return fmt.Errorf("failed to revoke user credentials: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreUser снимает пометку об удалении, если данные еще не удалены
+func (r *Repository) RestoreUser(ctx context.Context, userID int) error {
+	tag, err := r.db.Exec(ctx,
+		"UPDATE users SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL",
+		userID)
+
+	if err != nil {
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotDeleted
+	}
+
+	return nil
+}
+
+// PurgeDeletedUsers окончательно удаляет данные учетных записей, удаление которых
+// запрошено до before: анкеты, отклики, уведомления и учетные данные удаляются,
+// а строка users и ее события в журнале безопасности обезличиваются (освобождаются username и email).
+// Возвращает число обработанных учетных записей.
+func (r *Repository) PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`SELECT id FROM users
+        WHERE deleted_at < $1 AND purged_at IS NULL
+        ORDER BY deleted_at
+        LIMIT $2
+        FOR UPDATE SKIP LOCKED`,
+		before, purgeBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find deleted users: %w", err)
+	}
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan deleted user: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to find deleted users: %w", err)
+	}
+
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	for _, query := range []string{
+		`DELETE FROM responses
+        WHERE profile_id IN (SELECT id FROM profiles WHERE user_id = ANY($1))
+            OR responder_id IN (SELECT id FROM profiles WHERE user_id = ANY($1))`,
+		"DELETE FROM notifications WHERE user_id = ANY($1)",
+		"DELETE FROM profiles WHERE user_id = ANY($1)",
+		"DELETE FROM discovery_preferences WHERE user_id = ANY($1)",
+		"DELETE FROM refresh_tokens WHERE user_id = ANY($1)",
+		"DELETE FROM sessions WHERE user_id = ANY($1)",
+		"DELETE FROM api_keys WHERE user_id = ANY($1)",
+		"DELETE FROM user_identities WHERE user_id = ANY($1)",
+		"DELETE FROM user_recovery_codes WHERE user_id = ANY($1)",
+		"DELETE FROM email_verification_tokens WHERE user_id = ANY($1)",
+		"DELETE FROM password_reset_tokens WHERE user_id = ANY($1)",
+		"DELETE FROM magic_link_tokens WHERE user_id = ANY($1)",
+	} {
+		if _, err := tx.Exec(ctx, query, userIDs); err != nil {
+			return 0, fmt.Errorf("failed to purge user data: %w", err)
+		}
+	}
+
+	// Журнал безопасности не удаляется, но события учетных записей обезличиваются, в том
+	// числе неудачные входы без учетной записи, записанные по хэшу ее email
+	if _, err := tx.Exec(ctx,
+		`UPDATE security_events SET ip = '', user_agent = '', details = '{}'
+        WHERE user_id = ANY($1)
+            OR details->>'email_hash' IN (
+                SELECT encode(sha256(convert_to(lower(email), 'UTF8')), 'hex') FROM users WHERE id = ANY($1)
+            )`,
+		userIDs); err != nil {
+		return 0, fmt.Errorf("failed to anonymize security events: %w", err)
+	}
+
+	// Строку users не удаляем, чтобы не ломать ссылки из журналов, а обезличиваем
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET
+            username = 'deleted-' || id,
+            email = 'deleted-' || id || '@deleted.invalid',
+            password = '',
+            date_of_birth = NULL,
+            gender = NULL,
+            totp_secret = NULL,
+            totp_enabled_at = NULL,
+            totp_last_step = NULL,
+            roles = '{}',
+            purged_at = $2
+        WHERE id = ANY($1)`,
+		userIDs, time.Now()); err != nil {
+		return 0, fmt.Errorf("failed to anonymize users: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int64(len(userIDs)), nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrVerificationTokenInvalid = errors.New("verification token is invalid or already used")
+
+// CreateEmailVerificationToken регистрирует выпущенный токен подтверждения email
+func (r *Repository) CreateEmailVerificationToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		"INSERT INTO email_verification_tokens (jti, user_id, expires_at, created_at) VALUES ($1, $2, $3, $4)",
+		jti, userID, expiresAt, time.Now())
+
+	if err != nil {
+		return fmt.Errorf("failed to create verification token: %w", err)
+	}
+
+	return nil
+}
+
+// UseEmailVerificationToken погашает токен и помечает email пользователя подтвержденным
+func (r *Repository) UseEmailVerificationToken(ctx context.Context, jti string, userID int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+
+	var tokenUserID int
+	err = tx.QueryRow(ctx,
+		`UPDATE email_verification_tokens SET used_at = $1
+        WHERE jti = $2 AND user_id = $3 AND used_at IS NULL AND expires_at > $1
+        RETURNING user_id`,
+		now, jti, userID).Scan(&tokenUserID)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrVerificationTokenInvalid
+		}
+		return fmt.Errorf("failed to use verification token: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE users SET email_verified_at = $1 WHERE id = $2 AND email_verified_at IS NULL",
+		now, tokenUserID); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	models "passion-pals-backend/internal/models"
+)
+
+// earthRadiusKm средний радиус Земли
+const earthRadiusKm = 6371.0
+
+// gridSlackKm наибольшее смещение точки при привязке к сетке с запасом: на столько
+// расширяется ограничивающий прямоугольник, который отбирает анкеты по точным координатам
+const gridSlackKm = 1
+
+// feedQuery условия выборки анкет для смотрящего, общие для ленты и рекомендаций.
+// В запросе анкета p, ее учетная запись u и предпочтения подбора ее владельца dp (LEFT JOIN).
+type feedQuery struct {
+	conditions []string
+	args       []any
+	origin     *models.GeoPoint
+	// distance SQL-выражение расстояния от анкеты до смотрящего, "" - координаты смотрящего неизвестны
+	distance string
+}
+
+// newFeedQuery условия для смотрящего viewerID с координатами origin (nil - неизвестны,
+// иначе привязываются к сетке):
+// без его собственной анкеты и анкет удаленных учетных записей
+func newFeedQuery(viewerID int, origin *models.GeoPoint) *feedQuery {
+	q := &feedQuery{
+		conditions: []string{"u.deleted_at IS NULL", "u.purged_at IS NULL"},
+	}
+
+	if viewerID != 0 {
+		q.add("p.user_id <> ?", viewerID)
+	}
+	if origin != nil {
+		snapped := origin.Snapped()
+		q.origin = &snapped
+		q.distance = haversine(q.param(q.origin.Latitude), q.param(q.origin.Longitude))
+	}
+
+	return q
+}
+
+// add добавляет условие, заменяя "?" параметрами values по порядку
+func (q *feedQuery) add(condition string, values ...any) {
+	for _, value := range values {
+		condition = strings.Replace(condition, "?", q.param(value), 1)
+	}
+	q.conditions = append(q.conditions, condition)
+}
+
+// param добавляет параметр запроса и возвращает его плейсхолдер
+func (q *feedQuery) param(value any) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *feedQuery) where() string {
+	return strings.Join(q.conditions, " AND ")
+}
+
+// distanceColumn округленное вверх до целого километра расстояние до смотрящего:
+// точное положение по нему не вычислить. NULL, если расстояние неизвестно или скрыто.
+func (q *feedQuery) distanceColumn() string {
+	if q.distance == "" {
+		return "NULL::int"
+	}
+
+	return `CASE WHEN p.latitude IS NULL OR 'location' = ANY(p.hidden_fields) THEN NULL
+            ELSE GREATEST(ceil(` + q.distance + `), 1)::int END`
+}
+
+// within оставляет анкеты не дальше km от смотрящего. Индекс отбирает анкеты внутри
+// ограничивающего прямоугольника, точное расстояние считается только для них.
+// Анкеты со скрытым местоположением не попадают в выборку.
+func (q *feedQuery) within(km int) {
+	box := boundingBox(*q.origin, float64(km+gridSlackKm))
+	q.add("p.latitude BETWEEN ? AND ?", box.minLat, box.maxLat)
+	if !box.allLongitudes {
+		q.add("p.longitude BETWEEN ? AND ?", box.minLon, box.maxLon)
+	}
+	q.add(q.distance+" <= ?", km)
+	q.add("NOT ('location' = ANY(p.hidden_fields))")
+}
+
+// ageBetween оставляет анкеты с возрастом от minAge до maxAge (0 - без границы).
+// Анкеты со скрытым возрастом не попадают в выборку.
+func (q *feedQuery) ageBetween(minAge, maxAge int, now time.Time) {
+	// Возраст не меньше minAge: родился не позже, чем minAge лет назад
+	if minAge > 0 {
+		q.add("u.date_of_birth <= ?", now.AddDate(-minAge, 0, 0))
+	}
+	// Возраст не больше maxAge: родился позже, чем maxAge+1 лет назад
+	if maxAge > 0 {
+		q.add("u.date_of_birth > ?", now.AddDate(-maxAge-1, 0, 0))
+	}
+	if minAge > 0 || maxAge > 0 {
+		q.add("NOT ('age' = ANY(p.hidden_fields))")
+	}
+}
+
+// mutual оставляет анкеты, которые подходят под предпочтения смотрящего и под
+// предпочтения владельцев которых подходит сам смотрящий
+func (q *feedQuery) mutual(match *models.MutualMatch, now time.Time) {
+	prefs := match.Preferences
+
+	// Кандидат подходит смотрящему. Скрытые кандидатом поля проверить нельзя - такие анкеты не показываются.
+	q.ageBetween(deref(prefs.MinAge), deref(prefs.MaxAge), now)
+	if len(prefs.Genders) > 0 {
+		q.add("p.gender = ANY(?)", prefs.Genders)
+		q.add("NOT ('gender' = ANY(p.hidden_fields))")
+	}
+	// Ограничение расстояния действует, только если у смотрящего указаны координаты
+	if prefs.MaxDistanceKm != nil && q.origin != nil {
+		q.within(*prefs.MaxDistanceKm)
+	}
+
+	// Смотрящий подходит кандидату. Нет строки предпочтений - нет ограничений.
+	if match.Age > 0 {
+		q.add("(dp.min_age IS NULL OR dp.min_age <= ?)", match.Age)
+		q.add("(dp.max_age IS NULL OR dp.max_age >= ?)", match.Age)
+	} else {
+		q.add("dp.min_age IS NULL AND dp.max_age IS NULL")
+	}
+	q.add("(cardinality(COALESCE(dp.genders, '{}')) = 0 OR ? = ANY(dp.genders))", match.Gender)
+	// Ограничение расстояния кандидата действует, только если у кандидата указаны координаты
+	if q.distance != "" {
+		q.add("(dp.max_distance_km IS NULL OR p.latitude IS NULL OR " + q.distance + " <= dp.max_distance_km)")
+	} else {
+		q.add("(dp.max_distance_km IS NULL OR p.latitude IS NULL)")
+	}
+
+	// Цели знакомства должны совпадать, если указаны у обоих
+	if prefs.Intent != "" {
+		q.add("(COALESCE(dp.intent, '') = '' OR dp.intent = ?)", prefs.Intent)
+	}
+}
+
+func deref(value *int) int {
+	if value == nil {
+		return 0
+	}
+
+	return *value
+}
+
+// geoBox ограничивающий прямоугольник вокруг точки
+type geoBox struct {
+	minLat, maxLat float64
+	minLon, maxLon float64
+	// allLongitudes - прямоугольник захватывает полюс или пересекает 180-й меридиан,
+	// поэтому по долготе анкеты не отбираются
+	allLongitudes bool
+}
+
+// boundingBox возвращает прямоугольник, содержащий все точки не дальше km от origin
+func boundingBox(origin models.GeoPoint, km float64) geoBox {
+	angle := km / earthRadiusKm
+	deltaLat := angle * 180 / math.Pi
+
+	box := geoBox{minLat: origin.Latitude - deltaLat, maxLat: origin.Latitude + deltaLat}
+	if box.minLat <= -90 || box.maxLat >= 90 {
+		box.allLongitudes = true
+		return box
+	}
+
+	deltaLon := math.Asin(math.Sin(angle)/math.Cos(origin.Latitude*math.Pi/180)) * 180 / math.Pi
+	box.minLon, box.maxLon = origin.Longitude-deltaLon, origin.Longitude+deltaLon
+	if box.minLon < -180 || box.maxLon > 180 {
+		box.allLongitudes = true
+	}
+
+	return box
+}
+
+// snapColumn SQL-выражение координаты column, привязанной к сетке models.GridStep
+func snapColumn(column string) string {
+	return fmt.Sprintf("(round(%[1]s / %[2]g) * %[2]g)", column, models.GridStep)
+}
+
+// haversine SQL-выражение расстояния в километрах от анкеты p до точки с координатами
+// в параметрах lat и lon (формула гаверсинусов). Координаты анкеты привязываются к сетке.
+func haversine(lat, lon string) string {
+	return fmt.Sprintf(`(2 * %[3]g * asin(LEAST(1, sqrt(
+            power(sin(radians(%[4]s - %[1]s) / 2), 2)
+            + cos(radians(%[1]s)) * cos(radians(%[4]s)) * power(sin(radians(%[5]s - %[2]s) / 2), 2)))))`,
+		lat, lon, earthRadiusKm, snapColumn("p.latitude"), snapColumn("p.longitude"))
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	models "passion-pals-backend/internal/models"
+)
+
+// distanceKm расстояние между точками по формуле гаверсинусов, как в SQL-выражении haversine
+func distanceKm(a, b models.GeoPoint) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// destination точка на расстоянии km от origin по азимуту bearing (в градусах)
+func destination(origin models.GeoPoint, km, bearing float64) models.GeoPoint {
+	angle := km / earthRadiusKm
+	lat1, lon1 := origin.Latitude*math.Pi/180, origin.Longitude*math.Pi/180
+	theta := bearing * math.Pi / 180
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angle) + math.Cos(lat1)*math.Sin(angle)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(math.Sin(theta)*math.Sin(angle)*math.Cos(lat1), math.Cos(angle)-math.Sin(lat1)*math.Sin(lat2))
+
+	return models.GeoPoint{Latitude: lat2 * 180 / math.Pi, Longitude: lon2 * 180 / math.Pi}
+}
+
+func TestBoundingBox(t *testing.T) {
+	tests := []struct {
+		name          string
+		origin        models.GeoPoint
+		km            float64
+		allLongitudes bool
+	}{
+		{"equator", models.GeoPoint{Latitude: 0, Longitude: 0}, 100, false},
+		{"moscow", models.GeoPoint{Latitude: 55.75, Longitude: 37.62}, 500, false},
+		{"southern hemisphere", models.GeoPoint{Latitude: -33.87, Longitude: 151.21}, 50, false},
+		{"near antimeridian", models.GeoPoint{Latitude: 64.73, Longitude: 177.5}, 200, true},
+		{"near pole", models.GeoPoint{Latitude: 89.5, Longitude: 10}, 100, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box := boundingBox(tt.origin, tt.km)
+			if box.allLongitudes != tt.allLongitudes {
+				t.Fatalf("allLongitudes = %v, want %v", box.allLongitudes, tt.allLongitudes)
+			}
+
+			// Все точки на границе круга радиуса km должны попасть в прямоугольник
+			for bearing := 0.0; bearing < 360; bearing += 5 {
+				point := destination(tt.origin, tt.km*0.999, bearing)
+				if point.Latitude < box.minLat || point.Latitude > box.maxLat {
+					t.Errorf("bearing %v: latitude %v outside [%v, %v]", bearing, point.Latitude, box.minLat, box.maxLat)
+				}
+				if !box.allLongitudes && (point.Longitude < box.minLon || point.Longitude > box.maxLon) {
+					t.Errorf("bearing %v: longitude %v outside [%v, %v]", bearing, point.Longitude, box.minLon, box.maxLon)
+				}
+			}
+		})
+	}
+}
+
+// Прямоугольник не должен быть заметно шире круга: иначе индекс отбирает лишние анкеты
+func TestBoundingBoxTight(t *testing.T) {
+	origin := models.GeoPoint{Latitude: 55.75, Longitude: 37.62}
+	box := boundingBox(origin, 100)
+
+	north := distanceKm(origin, models.GeoPoint{Latitude: box.maxLat, Longitude: origin.Longitude})
+	east := distanceKm(origin, models.GeoPoint{Latitude: origin.Latitude, Longitude: box.maxLon})
+
+	if math.Abs(north-100) > 0.01 {
+		t.Errorf("north edge at %v km, want 100", north)
+	}
+	if east < 100 || east > 101 {
+		t.Errorf("east edge at %v km, want about 100", east)
+	}
+}
+
+func TestSnap(t *testing.T) {
+	tests := []struct {
+		degrees float64
+		want    float64
+	}{
+		{55.751244, 55.75},
+		{55.756, 55.76},
+		{-33.868820, -33.87},
+		{37.6173, 37.62},
+		{0.004, 0},
+		{-179.996, -180},
+		{90, 90},
+	}
+
+	for _, tt := range tests {
+		if got := models.Snap(tt.degrees); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("snap(%v) = %v, want %v", tt.degrees, got, tt.want)
+		}
+	}
+}
+
+// Привязка к сетке смещает точку не больше чем на gridSlackKm, поэтому расширенный
+// прямоугольник не теряет анкеты, которые после привязки оказались в пределах радиуса
+func TestGridSlack(t *testing.T) {
+	for _, point := range []models.GeoPoint{
+		{Latitude: 0.005, Longitude: 0.005},
+		{Latitude: 55.755, Longitude: 37.625},
+		{Latitude: -60.005, Longitude: 120.005},
+		{Latitude: 84.995, Longitude: -0.005},
+	} {
+		if shift := distanceKm(point, point.Snapped()); shift > gridSlackKm {
+			t.Errorf("%+v: snap shifts by %v km, more than %v", point, shift, gridSlackKm)
+		}
+	}
+}
+
+func TestNewFeedQuery(t *testing.T) {
+	origin := &models.GeoPoint{Latitude: 55.751244, Longitude: 37.618423}
+
+	q := newFeedQuery(7, origin)
+
+	if len(q.args) != 3 || q.args[0] != 7 {
+		t.Fatalf("args = %v, want viewer id and origin", q.args)
+	}
+	if lat, lon := q.args[1].(float64), q.args[2].(float64); math.Abs(lat-55.75) > 1e-9 || math.Abs(lon-37.62) > 1e-9 {
+		t.Errorf("origin params = %v, %v, want snapped 55.75, 37.62", lat, lon)
+	}
+	if origin.Latitude != 55.751244 {
+		t.Errorf("origin was modified: %+v", origin)
+	}
+	for _, column := range []string{snapColumn("p.latitude"), snapColumn("p.longitude")} {
+		if !strings.Contains(q.distance, column) {
+			t.Errorf("distance does not use snapped %s: %s", column, q.distance)
+		}
+	}
+	if !strings.Contains(q.where(), "p.user_id <> $1") {
+		t.Errorf("viewer's own profile is not excluded: %s", q.where())
+	}
+
+	anonymous := newFeedQuery(0, nil)
+	if len(anonymous.args) != 0 || anonymous.distance != "" || anonymous.distanceColumn() != "NULL::int" {
+		t.Errorf("unexpected query without viewer: %+v", anonymous)
+	}
+}
+
+func TestFeedQueryWithin(t *testing.T) {
+	q := newFeedQuery(1, &models.GeoPoint{Latitude: 55.75, Longitude: 37.62})
+	q.within(10)
+
+	where := q.where()
+	for _, condition := range []string{"p.latitude BETWEEN", "p.longitude BETWEEN", "'location' = ANY(p.hidden_fields)"} {
+		if !strings.Contains(where, condition) {
+			t.Errorf("where does not contain %q: %s", condition, where)
+		}
+	}
+
+	// Радиус передается целым числом, прямоугольник расширен на gridSlackKm
+	if km := q.args[len(q.args)-1]; km != 10 {
+		t.Errorf("radius param = %v, want 10", km)
+	}
+	minLat := q.args[3].(float64)
+	if want := 55.75 - float64(10+gridSlackKm)/earthRadiusKm*180/math.Pi; math.Abs(minLat-want) > 1e-9 {
+		t.Errorf("minLat = %v, want %v", minLat, want)
+	}
+}
+
+func TestFeedQueryAgeBetween(t *testing.T) {
+	tests := []struct {
+		name           string
+		minAge, maxAge int
+		conditions     int
+	}{
+		{"no bounds", 0, 0, 0},
+		{"min only", 18, 0, 2},
+		{"max only", 0, 30, 2},
+		{"both", 18, 30, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newFeedQuery(0, nil)
+			before := len(q.conditions)
+			q.ageBetween(tt.minAge, tt.maxAge, time.Now())
+			if got := len(q.conditions) - before; got != tt.conditions {
+				t.Errorf("added %d conditions, want %d: %v", got, tt.conditions, q.conditions[before:])
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	models "passion-pals-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// foreignKeyViolation код ошибки Postgres при нарушении внешнего ключа
+const foreignKeyViolation = "23503"
+
+var (
+	ErrInterestNotFound         = errors.New("interest not found")
+	ErrInterestExists           = errors.New("interest with this slug, name or synonym already exists")
+	ErrInterestCategoryNotFound = errors.New("interest category not found")
+	ErrInterestCategoryExists   = errors.New("interest category with this slug already exists")
+)
+
+// GetInterestCatalog возвращает каталог интересов по категориям. Если query не пуст,
+// в каталог попадают только интересы, у которых slug, название или синоним содержат query.
+func (r *Repository) GetInterestCatalog(ctx context.Context, query string) ([]models.InterestCategory, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT c.id, c.slug, c.name, i.id, i.slug, i.name,
+            ARRAY(SELECT s.synonym FROM interest_synonyms s WHERE s.interest_id = i.id ORDER BY s.synonym)
+        FROM interest_categories c
+        JOIN interests i ON i.category_id = c.id
+        WHERE $1 = ''
+            OR i.slug LIKE '%' || $1 || '%'
+            OR lower(i.name) LIKE '%' || $1 || '%'
+            OR EXISTS (SELECT 1 FROM interest_synonyms s WHERE s.interest_id = i.id AND s.synonym LIKE '%' || $1 || '%')
+        ORDER BY c.name, i.name`,
+		escapeLike(strings.ToLower(strings.TrimSpace(query))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query interests: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []models.InterestCategory{}
+
+	for rows.Next() {
+		var category models.InterestCategory
+		var interest models.Interest
+
+		if err := rows.Scan(&category.ID, &category.Slug, &category.Name,
+			&interest.ID, &interest.Slug, &interest.Name, &interest.Synonyms); err != nil {
+			return nil, fmt.Errorf("failed to scan interest: %w", err)
+		}
+		interest.CategoryID = category.ID
+
+		// Строки отсортированы по категории, поэтому достаточно сравнить с последней
+		if n := len(categories); n == 0 || categories[n-1].ID != category.ID {
+			categories = append(categories, category)
+		}
+		last := &categories[len(categories)-1]
+		last.Interests = append(last.Interests, interest)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return categories, nil
+}
+
+// ResolveInterests сопоставляет введенные пользователем значения (slug, название или синоним,
+// без учета регистра) с интересами каталога. Возвращает id найденных интересов без повторов
+// в порядке значений и значения, которых в каталоге нет.
+func (r *Repository) ResolveInterests(ctx context.Context, terms []string) ([]int, []string, error) {
+	normalized := make([]string, 0, len(terms))
+	for _, term := range terms {
+		normalized = append(normalized, strings.ToLower(strings.TrimSpace(term)))
+	}
+
+	rows, err := r.db.Query(ctx,
+		`SELECT t.term, COALESCE(i.id, s.interest_id)
+        FROM unnest($1::text[]) AS t (term)
+        LEFT JOIN interests i ON i.slug = t.term OR lower(i.name) = t.term
+        LEFT JOIN interest_synonyms s ON s.synonym = t.term
+        WHERE i.id IS NOT NULL OR s.interest_id IS NOT NULL`,
+		normalized)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve interests: %w", err)
+	}
+	defer rows.Close()
+
+	resolved := make(map[string]int, len(terms))
+	for rows.Next() {
+		var term string
+		var interestID int
+
+		if err := rows.Scan(&term, &interestID); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan interest: %w", err)
+		}
+		resolved[term] = interestID
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	interestIDs := []int{}
+	var unknown []string
+
+	for i, term := range normalized {
+		interestID, ok := resolved[term]
+		if !ok {
+			unknown = append(unknown, terms[i])
+			continue
+		}
+		if !slices.Contains(interestIDs, interestID) {
+			interestIDs = append(interestIDs, interestID)
+		}
+	}
+
+	return interestIDs, unknown, nil
+}
+
+// GetProfileInterests возвращает интересы анкеты пользователя
+func (r *Repository) GetProfileInterests(ctx context.Context, userID int) ([]models.Interest, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT i.id, i.slug, i.name, i.category_id,
+            ARRAY(SELECT s.synonym FROM interest_synonyms s WHERE s.interest_id = i.id ORDER BY s.synonym)
+        FROM profile_interests pi
+        JOIN profiles p ON p.id = pi.profile_id
+        JOIN interests i ON i.id = pi.interest_id
+        WHERE p.user_id = $1
+        ORDER BY i.name`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query profile interests: %w", err)
+	}
+	defer rows.Close()
+
+	interests := []models.Interest{}
+	for rows.Next() {
+		var interest models.Interest
+
+		if err := rows.Scan(&interest.ID, &interest.Slug, &interest.Name, &interest.CategoryID, &interest.Synonyms); err != nil {
+			return nil, fmt.Errorf("failed to scan interest: %w", err)
+		}
+		interests = append(interests, interest)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return interests, nil
+}
+
+// SetProfileInterests заменяет интересы анкеты пользователя и обновляет updated_at анкеты
+func (r *Repository) SetProfileInterests(ctx context.Context, userID int, interestIDs []int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var profileID int
+	err = tx.QueryRow(ctx,
+		`UPDATE profiles SET updated_at = GREATEST(now(), updated_at + interval '1 microsecond')
+        WHERE user_id = $1
+        RETURNING id`,
+		userID).Scan(&profileID)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrProfileNotFound
+		}
+		return fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM profile_interests WHERE profile_id = $1", profileID); err != nil {
+		return fmt.Errorf("failed to clear profile interests: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO profile_interests (profile_id, interest_id)
+        SELECT $1, unnest($2::int[])
+        ON CONFLICT DO NOTHING`,
+		profileID, interestIDs); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return ErrInterestNotFound
+		}
+		return fmt.Errorf("failed to set profile interests: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CreateInterestCategory добавляет категорию в каталог
+func (r *Repository) CreateInterestCategory(ctx context.Context, category *models.InterestCategory) error {
+	err := r.db.QueryRow(ctx,
+		"INSERT INTO interest_categories (slug, name) VALUES ($1, $2) RETURNING id",
+		category.Slug, category.Name).Scan(&category.ID)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return ErrInterestCategoryExists
+		}
+		return fmt.Errorf("failed to create interest category: %w", err)
+	}
+
+	return nil
+}
+
+// CreateInterest добавляет интерес с синонимами в каталог
+func (r *Repository) CreateInterest(ctx context.Context, interest *models.Interest) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		"INSERT INTO interests (slug, name, category_id) VALUES ($1, $2, $3) RETURNING id",
+		interest.Slug, interest.Name, interest.CategoryID).Scan(&interest.ID)
+
+	if err != nil {
+		return interestWriteError(err, "failed to create interest")
+	}
+
+	if err := replaceSynonyms(ctx, tx, interest.ID, interest.Synonyms); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateInterest изменяет заданные поля интереса и возвращает его новое состояние
+func (r *Repository) UpdateInterest(ctx context.Context, interestID int, update models.InterestUpdate) (*models.Interest, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var interest models.Interest
+	err = tx.QueryRow(ctx,
+		`UPDATE interests SET
+            name        = COALESCE($2, name),
+            category_id = COALESCE($3, category_id)
+        WHERE id = $1
+        RETURNING id, slug, name, category_id`,
+		interestID, update.Name, update.CategoryID).Scan(&interest.ID, &interest.Slug, &interest.Name, &interest.CategoryID)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInterestNotFound
+		}
+		return nil, interestWriteError(err, "failed to update interest")
+	}
+
+	if update.Synonyms != nil {
+		if err := replaceSynonyms(ctx, tx, interestID, update.Synonyms); err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.QueryRow(ctx,
+		"SELECT ARRAY(SELECT synonym FROM interest_synonyms WHERE interest_id = $1 ORDER BY synonym)",
+		interestID).Scan(&interest.Synonyms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get synonyms: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &interest, nil
+}
+
+// DeleteInterest удаляет интерес из каталога вместе с синонимами и привязками к анкетам
+func (r *Repository) DeleteInterest(ctx context.Context, interestID int) error {
+	tag, err := r.db.Exec(ctx, "DELETE FROM interests WHERE id = $1", interestID)
+	if err != nil {
+		return fmt.Errorf("failed to delete interest: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrInterestNotFound
+	}
+
+	return nil
+}
+
+// replaceSynonyms заменяет синонимы интереса
+func replaceSynonyms(ctx context.Context, tx pgx.Tx, interestID int, synonyms []string) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM interest_synonyms WHERE interest_id = $1", interestID); err != nil {
+		return fmt.Errorf("failed to clear synonyms: %w", err)
+	}
+
+	if len(synonyms) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx,
+		"INSERT INTO interest_synonyms (synonym, interest_id) SELECT unnest($1::text[]), $2",
+		synonyms, interestID); err != nil {
+		return interestWriteError(err, "failed to add synonyms")
+	}
+
+	return nil
+}
+
+// interestWriteError переводит нарушения ограничений каталога в ошибки репозитория
+func interestWriteError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolation:
+			return ErrInterestExists
+		case foreignKeyViolation:
+			return ErrInterestCategoryNotFound
+		}
+	}
+
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
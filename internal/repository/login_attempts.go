@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GetLoginLockedUntil возвращает самую позднюю действующую блокировку среди ключей
+func (r *Repository) GetLoginLockedUntil(ctx context.Context, keys []string) (*time.Time, error) {
+	var lockedUntil *time.Time
+
+	err := r.db.QueryRow(ctx,
+		"SELECT max(locked_until) FROM login_attempts WHERE key = ANY($1) AND locked_until > $2",
+		keys, time.Now()).Scan(&lockedUntil)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login lock: %w", err)
+	}
+
+	return lockedUntil, nil
+}
+
+// AddLoginFailure увеличивает счетчик неудач по ключу и возвращает его новое значение.
+// Если предыдущая неудача была раньше windowStart, счетчик начинается заново.
+func (r *Repository) AddLoginFailure(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	var failures int
+
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO login_attempts (key, failures, last_failure_at) VALUES ($1, 1, $2)
+        ON CONFLICT (key) DO UPDATE SET
+            failures = CASE
+                WHEN login_attempts.last_failure_at < $3 THEN 1
+                ELSE login_attempts.failures + 1
+            END,
+            last_failure_at = EXCLUDED.last_failure_at
+        RETURNING failures`,
+		key, time.Now(), windowStart).Scan(&failures)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to add login failure: %w", err)
+	}
+
+	return failures, nil
+}
+
+// LockLogin блокирует вход по ключу до lockedUntil
+func (r *Repository) LockLogin(ctx context.Context, key string, lockedUntil time.Time) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE login_attempts SET locked_until = $1 WHERE key = $2",
+		lockedUntil, key)
+
+	if err != nil {
+		return fmt.Errorf("failed to lock login: %w", err)
+	}
+
+	return nil
+}
+
+// ResetLoginFailures сбрасывает счетчик неудач по ключу
+func (r *Repository) ResetLoginFailures(ctx context.Context, key string) error {
+	_, err := r.db.Exec(ctx, "DELETE FROM login_attempts WHERE key = $1", key)
+
+	if err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeLoginAttempts удаляет устаревшие счетчики без действующей блокировки
+func (r *Repository) PurgeLoginAttempts(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx,
+		"DELETE FROM login_attempts WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < $2)",
+		before, time.Now())
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge login attempts: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrMagicLinkInvalid = errors.New("magic link is invalid or already used")
+
+// CreateMagicLinkToken регистрирует выпущенную ссылку для входа
+func (r *Repository) CreateMagicLinkToken(ctx context.Context, jti string, userID int, nonceHash string, expiresAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		"INSERT INTO magic_link_tokens (jti, user_id, nonce_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)",
+		jti, userID, nonceHash, expiresAt, time.Now())
+
+	if err != nil {
+		return fmt.Errorf("failed to create magic link token: %w", err)
+	}
+
+	return nil
+}
+
+// UseMagicLinkToken погашает ссылку, выпущенную для браузера с nonceHash.
+// Переход по ссылке из письма подтверждает владение адресом, поэтому email
+// пользователя заодно помечается подтвержденным.
+func (r *Repository) UseMagicLinkToken(ctx context.Context, jti string, userID int, nonceHash string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+
+	var tokenUserID int
+	err = tx.QueryRow(ctx,
+		`UPDATE magic_link_tokens SET used_at = $1
+        WHERE jti = $2 AND user_id = $3 AND nonce_hash = $4 AND used_at IS NULL AND expires_at > $1
+        RETURNING user_id`,
+		now, jti, userID, nonceHash).Scan(&tokenUserID)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrMagicLinkInvalid
+		}
+		return fmt.Errorf("failed to use magic link token: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE users SET email_verified_at = $1 WHERE id = $2 AND email_verified_at IS NULL",
+		now, tokenUserID); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	models "passion-pals-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrAuthRequestNotFound = errors.New("auth request not found or expired")
+	ErrIdentityNotFound    = errors.New("identity not found")
+	ErrEmailNotVerified    = errors.New("email is not verified")
+)
+
+// CreateOIDCAuthRequest сохраняет параметры начатого входа через провайдера
+func (r *Repository) CreateOIDCAuthRequest(ctx context.Context, req *models.OIDCAuthRequest) error {
+	_, err := r.db.Exec(ctx,
+		"INSERT INTO oidc_auth_requests (state, provider, nonce, code_verifier, expires_at) VALUES ($1, $2, $3, $4, $5)",
+		req.State, req.Provider, req.Nonce, req.CodeVerifier, req.ExpiresAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create auth request: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeOIDCAuthRequest извлекает и удаляет попытку входа по state (одноразово)
+func (r *Repository) ConsumeOIDCAuthRequest(ctx context.Context, state, provider string) (*models.OIDCAuthRequest, error) {
+	var req models.OIDCAuthRequest
+
+	err := r.db.QueryRow(ctx,
+		`DELETE FROM oidc_auth_requests
+        WHERE state = $1 AND provider = $2
+        RETURNING state, provider, nonce, code_verifier, expires_at`,
+		state, provider).Scan(&req.State, &req.Provider, &req.Nonce, &req.CodeVerifier, &req.ExpiresAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAuthRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to consume auth request: %w", err)
+	}
+
+	if time.Now().After(req.ExpiresAt) {
+		return nil, ErrAuthRequestNotFound
+	}
+
+	// Заодно удаляем брошенные попытки входа
+	if _, err := r.db.Exec(ctx, "DELETE FROM oidc_auth_requests WHERE expires_at < $1", time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to purge auth requests: %w", err)
+	}
+
+	return &req, nil
+}
+
+// FindUserIDByIdentity ищет пользователя, привязанного к внешней учетной записи
+func (r *Repository) FindUserIDByIdentity(ctx context.Context, provider, subject string) (int, error) {
+	var userID int
+
+	err := r.db.QueryRow(ctx,
+		"SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2",
+		provider, subject).Scan(&userID)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrIdentityNotFound
+		}
+		return 0, fmt.Errorf("failed to find identity: %w", err)
+	}
+
+	return userID, nil
+}
+
+// LinkIdentity привязывает внешнюю учетную запись к существующему пользователю.
+// Привязка возможна только к учетной записи с подтвержденным email, иначе ErrEmailNotVerified.
+func (r *Repository) LinkIdentity(ctx context.Context, userID int, identity *models.ExternalIdentity) error {
+	tag, err := r.db.Exec(ctx,
+		`INSERT INTO user_identities (provider, subject, user_id, email, created_at)
+        SELECT $1, $2, id, $4, $5 FROM users WHERE id = $3 AND email_verified_at IS NOT NULL`,
+		identity.Provider, identity.Subject, userID, identity.Email, time.Now())
+
+	if err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrEmailNotVerified
+	}
+
+	return nil
+}
+
+// CreateUserWithIdentity создает пользователя без пароля, его профиль и привязку к провайдеру
+func (r *Repository) CreateUserWithIdentity(ctx context.Context, identity *models.ExternalIdentity) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+
+	var emailVerifiedAt *time.Time
+	if identity.EmailVerified {
+		emailVerifiedAt = &now
+	}
+
+	var userID int
+	err = tx.QueryRow(ctx,
+		"INSERT INTO users (username, email, password, email_verified_at) VALUES ($1, $2, $3, $4) RETURNING id",
+		identity.Username, identity.Email, "", emailVerifiedAt).Scan(&userID)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		"INSERT INTO profiles (user_id, created_at, updated_at) VALUES ($1, $2, $3)",
+		userID, now, now); err != nil {
+		return 0, fmt.Errorf("failed to create profile: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		"INSERT INTO user_identities (provider, subject, user_id, email, created_at) VALUES ($1, $2, $3, $4, $5)",
+		identity.Provider, identity.Subject, userID, identity.Email, now); err != nil {
+		return 0, fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return userID, nil
+}
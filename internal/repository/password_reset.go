@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrResetTokenInvalid = errors.New("reset token is invalid, expired or already used")
+
+// CreatePasswordResetToken сохраняет хэш токена сброса пароля
+func (r *Repository) CreatePasswordResetToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		"INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4)",
+		userID, tokenHash, expiresAt, time.Now())
+
+	if err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword погашает токен сброса и устанавливает новый хэш пароля.
+// Остальные неиспользованные токены пользователя также погашаются.
+func (r *Repository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+
+	var userID int
+	err = tx.QueryRow(ctx,
+		`UPDATE password_reset_tokens SET used_at = $1
+        WHERE token_hash = $2 AND used_at IS NULL AND expires_at > $1
+        RETURNING user_id`,
+		now, tokenHash).Scan(&userID)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrResetTokenInvalid
+		}
+		return 0, fmt.Errorf("failed to use reset token: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE users SET password = $1 WHERE id = $2",
+		passwordHash, userID); err != nil {
+		return 0, fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE password_reset_tokens SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL",
+		now, userID); err != nil {
+		return 0, fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,356 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	models "passion-pals-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrPhotoNotFound      = errors.New("photo not found")
+	ErrPhotoLimitReached  = errors.New("photo limit reached")
+	ErrPhotoOrderMismatch = errors.New("photo order must list every photo exactly once")
+)
+
+// storedVariant копия фотографии в столбце profile_photos.variants
+type storedVariant struct {
+	Size   int    `json:"size"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
+	Key    string `json:"key"`
+}
+
+// GetProfilePhotos возвращает галерею анкеты по порядку
+func (r *Repository) GetProfilePhotos(ctx context.Context, profileID int) ([]*models.ProfilePhoto, error) {
+	return r.queryPhotos(ctx, "profile_id = $1", profileID)
+}
+
+// GetUserPhotos возвращает галерею анкеты пользователя по порядку
+func (r *Repository) GetUserPhotos(ctx context.Context, userID int) ([]*models.ProfilePhoto, error) {
+	return r.queryPhotos(ctx, "profile_id = (SELECT id FROM profiles WHERE user_id = $1)", userID)
+}
+
+// AddProfilePhoto добавляет фотографию в конец галереи пользователя, если в ней меньше
+// maxPhotos фотографий. Первая фотография становится основной, ее avatarURL - аватаром анкеты.
+func (r *Repository) AddProfilePhoto(ctx context.Context, userID int, variants []models.PhotoVariant, avatarURL string, maxPhotos int) (*models.ProfilePhoto, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	profileID, err := lockProfile(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, "SELECT count(*) FROM profile_photos WHERE profile_id = $1", profileID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to count photos: %w", err)
+	}
+	if count >= maxPhotos {
+		return nil, ErrPhotoLimitReached
+	}
+
+	stored := make([]storedVariant, 0, len(variants))
+	for _, variant := range variants {
+		stored = append(stored, storedVariant(variant))
+	}
+	encoded, err := json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode photo variants: %w", err)
+	}
+
+	photo := models.ProfilePhoto{
+		Position:  count,
+		IsPrimary: count == 0,
+		Variants:  variants,
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO profile_photos (profile_id, position, is_primary, variants, avatar_url)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at`,
+		profileID, photo.Position, photo.IsPrimary, encoded, avatarURL).Scan(&photo.ID, &photo.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add photo: %w", err)
+	}
+
+	if photo.IsPrimary {
+		if err := syncAvatar(ctx, tx, profileID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &photo, nil
+}
+
+// DeleteProfilePhoto удаляет фотографию из галереи пользователя. Если она была основной,
+// основной становится первая из оставшихся. Файлы удаляются из хранилища в фоне.
+func (r *Repository) DeleteProfilePhoto(ctx context.Context, userID, photoID int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	profileID, err := lockProfile(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	var position int
+	var isPrimary bool
+
+	err = tx.QueryRow(ctx,
+		"DELETE FROM profile_photos WHERE id = $1 AND profile_id = $2 RETURNING position, is_primary",
+		photoID, profileID).Scan(&position, &isPrimary)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPhotoNotFound
+		}
+		return fmt.Errorf("failed to delete photo: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE profile_photos SET position = position - 1 WHERE profile_id = $1 AND position > $2",
+		profileID, position)
+	if err != nil {
+		return fmt.Errorf("failed to reorder photos: %w", err)
+	}
+
+	if isPrimary {
+		_, err = tx.Exec(ctx,
+			`UPDATE profile_photos SET is_primary = true
+            WHERE id = (SELECT id FROM profile_photos WHERE profile_id = $1 ORDER BY position LIMIT 1)`,
+			profileID)
+		if err != nil {
+			return fmt.Errorf("failed to set primary photo: %w", err)
+		}
+
+		if err := syncAvatar(ctx, tx, profileID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetPrimaryPhoto делает фотографию основной, а ее копию - аватаром анкеты
+func (r *Repository) SetPrimaryPhoto(ctx context.Context, userID, photoID int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	profileID, err := lockProfile(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM profile_photos WHERE id = $1 AND profile_id = $2)",
+		photoID, profileID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to find photo: %w", err)
+	}
+	if !exists {
+		return ErrPhotoNotFound
+	}
+
+	// Два запроса: уникальный индекс основной фотографии проверяется построчно
+	if _, err := tx.Exec(ctx, "UPDATE profile_photos SET is_primary = false WHERE profile_id = $1 AND is_primary", profileID); err != nil {
+		return fmt.Errorf("failed to set primary photo: %w", err)
+	}
+	if _, err := tx.Exec(ctx, "UPDATE profile_photos SET is_primary = true WHERE id = $1", photoID); err != nil {
+		return fmt.Errorf("failed to set primary photo: %w", err)
+	}
+
+	if err := syncAvatar(ctx, tx, profileID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReorderProfilePhotos расставляет фотографии галереи в порядке photoIDs, который должен
+// содержать каждую фотографию ровно один раз, и возвращает галерею
+func (r *Repository) ReorderProfilePhotos(ctx context.Context, userID int, photoIDs []int) ([]*models.ProfilePhoto, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	profileID, err := lockProfile(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, "SELECT id FROM profile_photos WHERE profile_id = $1", profileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	var current []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan photo: %w", err)
+		}
+		current = append(current, id)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	requested := slices.Clone(photoIDs)
+	slices.Sort(requested)
+	slices.Sort(current)
+	if !slices.Equal(requested, current) {
+		return nil, ErrPhotoOrderMismatch
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE profile_photos p SET position = o.ord - 1
+        FROM unnest($1::int[]) WITH ORDINALITY AS o(id, ord)
+        WHERE p.id = o.id AND p.profile_id = $2`,
+		photoIDs, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reorder photos: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetProfilePhotos(ctx, profileID)
+}
+
+// GetMediaDeletions возвращает до limit ключей файлов, ожидающих удаления из хранилища
+func (r *Repository) GetMediaDeletions(ctx context.Context, limit int) ([]string, error) {
+	rows, err := r.db.Query(ctx, "SELECT key FROM media_deletions ORDER BY created_at LIMIT $1", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan media deletion: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return keys, nil
+}
+
+// CompleteMediaDeletions убирает из очереди ключи файлов, удаленных из хранилища
+func (r *Repository) CompleteMediaDeletions(ctx context.Context, keys []string) error {
+	if _, err := r.db.Exec(ctx, "DELETE FROM media_deletions WHERE key = ANY($1)", keys); err != nil {
+		return fmt.Errorf("failed to complete media deletions: %w", err)
+	}
+
+	return nil
+}
+
+// lockProfile блокирует анкету пользователя до конца транзакции, чтобы изменения
+// галереи не пересекались, и возвращает ее id
+func lockProfile(ctx context.Context, tx pgx.Tx, userID int) (int, error) {
+	var profileID int
+
+	err := tx.QueryRow(ctx,
+		`SELECT p.id FROM profiles p JOIN users u ON p.user_id = u.id
+        WHERE p.user_id = $1 AND u.deleted_at IS NULL
+        FOR UPDATE OF p`,
+		userID).Scan(&profileID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrProfileNotFound
+		}
+		return 0, fmt.Errorf("failed to lock profile: %w", err)
+	}
+
+	return profileID, nil
+}
+
+// syncAvatar делает аватаром анкеты копию основной фотографии (или очищает его,
+// если фотографий не осталось). Аватар - часть анкеты, поэтому меняется и ее ETag.
+func syncAvatar(ctx context.Context, tx pgx.Tx, profileID int) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE profiles SET
+            avatar_url = COALESCE((SELECT avatar_url FROM profile_photos WHERE profile_id = $1 AND is_primary), ''),
+            updated_at = GREATEST(now(), updated_at + interval '1 microsecond')
+        WHERE id = $1`,
+		profileID)
+	if err != nil {
+		return fmt.Errorf("failed to update avatar: %w", err)
+	}
+
+	return nil
+}
+
+// queryPhotos возвращает фотографии, отобранные условием condition, по порядку
+func (r *Repository) queryPhotos(ctx context.Context, condition string, args ...any) ([]*models.ProfilePhoto, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT id, position, is_primary, variants, created_at
+        FROM profile_photos
+        WHERE `+condition+`
+        ORDER BY position, id`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get photos: %w", err)
+	}
+	defer rows.Close()
+
+	photos := []*models.ProfilePhoto{}
+	for rows.Next() {
+		var photo models.ProfilePhoto
+		var stored []storedVariant
+
+		if err := rows.Scan(&photo.ID, &photo.Position, &photo.IsPrimary, &stored, &photo.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan photo: %w", err)
+		}
+
+		for _, variant := range stored {
+			photo.Variants = append(photo.Variants, models.PhotoVariant(variant))
+		}
+		photos = append(photos, &photo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return photos, nil
+}
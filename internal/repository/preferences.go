@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	models "passion-pals-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetDiscoveryPreferences возвращает предпочтения подбора пользователя. Если пользователь
+// их не задавал, возвращаются пустые предпочтения (без ограничений).
+func (r *Repository) GetDiscoveryPreferences(ctx context.Context, userID int) (*models.DiscoveryPreferences, error) {
+	var prefs models.DiscoveryPreferences
+
+	err := r.db.QueryRow(ctx,
+		`SELECT min_age, max_age, genders, max_distance_km, intent, updated_at
+        FROM discovery_preferences
+        WHERE user_id = $1`,
+		userID).Scan(&prefs.MinAge, &prefs.MaxAge, &prefs.Genders, &prefs.MaxDistanceKm, &prefs.Intent, &prefs.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.DiscoveryPreferences{Genders: []string{}}, nil
+		}
+		return nil, fmt.Errorf("failed to get discovery preferences: %w", err)
+	}
+
+	return &prefs, nil
+}
+
+// SetDiscoveryPreferences заменяет предпочтения подбора пользователя
+func (r *Repository) SetDiscoveryPreferences(ctx context.Context, userID int, prefs models.DiscoveryPreferences) (*models.DiscoveryPreferences, error) {
+	var saved models.DiscoveryPreferences
+
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO discovery_preferences (user_id, min_age, max_age, genders, max_distance_km, intent, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, now())
+        ON CONFLICT (user_id) DO UPDATE SET
+            min_age = EXCLUDED.min_age,
+            max_age = EXCLUDED.max_age,
+            genders = EXCLUDED.genders,
+            max_distance_km = EXCLUDED.max_distance_km,
+            intent = EXCLUDED.intent,
+            updated_at = EXCLUDED.updated_at
+        RETURNING min_age, max_age, genders, max_distance_km, intent, updated_at`,
+		userID, prefs.MinAge, prefs.MaxAge, prefs.Genders, prefs.MaxDistanceKm, prefs.Intent).Scan(
+		&saved.MinAge, &saved.MaxAge, &saved.Genders, &saved.MaxDistanceKm, &saved.Intent, &saved.UpdatedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to save discovery preferences: %w", err)
+	}
+
+	return &saved, nil
+}
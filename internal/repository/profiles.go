@@ -0,0 +1,356 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	models "passion-pals-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// profileAgeColumn возраст по дате рождения на момент запроса (profiles.age фиксируется
+// при регистрации и устаревает); у учетных записей без даты рождения - profiles.age
+const profileAgeColumn = `COALESCE(date_part('year', age(u.date_of_birth))::int, p.age)`
+
+// profileInterestsColumn slug интересов анкеты p
+const profileInterestsColumn = `ARRAY(SELECT i.slug FROM profile_interests pi JOIN interests i ON i.id = pi.interest_id
+            WHERE pi.profile_id = p.id ORDER BY i.slug)`
+
+// profileLocationColumns город и точные координаты анкеты p (координаты - только для владельца)
+const profileLocationColumns = `p.city, p.latitude, p.longitude`
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrProfileModified = errors.New("profile was modified concurrently")
+)
+
+// UpdateProfile изменяет заданные (не nil) поля анкеты, если она не менялась после
+// expectedUpdatedAt, и возвращает обновленную анкету. updated_at всегда растет,
+// даже если два изменения пришлись на одну микросекунду.
+func (r *Repository) UpdateProfile(ctx context.Context, userID int, update models.ProfileUpdate, expectedUpdatedAt time.Time) (*models.UserProfile, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var profile models.UserProfile
+
+	// Без нового местоположения параметры не используются, но должны быть переданы
+	location := update.Location
+	if location == nil {
+		location = &models.ProfileLocation{}
+	}
+	setLocation := update.Location != nil
+
+	err = tx.QueryRow(ctx,
+		`UPDATE profiles p SET
+            about_me    = COALESCE($3, p.about_me),
+            avatar_url  = COALESCE($4, p.avatar_url),
+            gender      = COALESCE($5, p.gender),
+            looking_for = COALESCE($6, p.looking_for),
+            hidden_fields = COALESCE($7, p.hidden_fields),
+            city        = CASE WHEN $8 THEN $9 ELSE p.city END,
+            latitude    = CASE WHEN $8 THEN $10::double precision ELSE p.latitude END,
+            longitude   = CASE WHEN $8 THEN $11::double precision ELSE p.longitude END,
+            location_updated_at = CASE WHEN $8 AND $10::double precision IS NOT NULL
+                AND (p.latitude IS DISTINCT FROM $10 OR p.longitude IS DISTINCT FROM $11)
+                THEN now() ELSE p.location_updated_at END,
+            updated_at  = GREATEST(now(), p.updated_at + interval '1 microsecond')
+        FROM users u
+        WHERE p.user_id = u.id AND p.user_id = $1 AND p.updated_at = $2 AND u.deleted_at IS NULL
+        RETURNING p.id, p.user_id, u.username, `+profileAgeColumn+`, p.avatar_url, p.about_me, p.gender, p.looking_for,
+            p.hidden_fields, `+profileInterestsColumn+`, `+profileLocationColumns+`, p.created_at, p.updated_at`,
+		userID, expectedUpdatedAt, update.AboutMe, update.AvatarURL, update.Gender, update.LookingFor, update.HiddenFields,
+		setLocation, location.City, location.Latitude, location.Longitude).Scan(
+		&profile.ID, &profile.UserID, &profile.Username, &profile.Age, &profile.AvatarUrl, &profile.AboutMe, &profile.Gender,
+		&profile.LookingFor, &profile.HiddenFields, &profile.Interests, &profile.City, &profile.Latitude, &profile.Longitude,
+		&profile.CreatedAt, &profile.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.profileUpdateConflict(ctx, tx, userID)
+		}
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	// Пол хранится и в учетной записи, держим значения согласованными
+	if update.Gender != nil {
+		if _, err := tx.Exec(ctx, "UPDATE users SET gender = $1 WHERE id = $2", *update.Gender, userID); err != nil {
+			return nil, fmt.Errorf("failed to update user gender: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &profile, nil
+}
+
+// GetLocationChange возвращает координаты анкеты пользователя (nil - не указаны) и время
+// их последнего изменения (nil - не менялись)
+func (r *Repository) GetLocationChange(ctx context.Context, userID int) (*models.GeoPoint, *time.Time, error) {
+	var latitude, longitude *float64
+	var updatedAt *time.Time
+
+	err := r.db.QueryRow(ctx,
+		"SELECT latitude, longitude, location_updated_at FROM profiles WHERE user_id = $1",
+		userID).Scan(&latitude, &longitude, &updatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrProfileNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get profile location: %w", err)
+	}
+
+	if latitude == nil || longitude == nil {
+		return nil, updatedAt, nil
+	}
+
+	return &models.GeoPoint{Latitude: *latitude, Longitude: *longitude}, updatedAt, nil
+}
+
+// profileUpdateConflict выясняет, почему UpdateProfile не изменил ни одной строки
+func (r *Repository) profileUpdateConflict(ctx context.Context, tx pgx.Tx, userID int) error {
+	var exists bool
+
+	err := tx.QueryRow(ctx,
+		`SELECT EXISTS (
+            SELECT 1 FROM profiles p JOIN users u ON p.user_id = u.id
+            WHERE p.user_id = $1 AND u.deleted_at IS NULL
+        )`,
+		userID).Scan(&exists)
+
+	if err != nil {
+		return fmt.Errorf("failed to check profile: %w", err)
+	}
+
+	if !exists {
+		return ErrProfileNotFound
+	}
+
+	return ErrProfileModified
+}
+
+// GetProfileByID возвращает анкету по ее id. Анкеты удаленных учетных записей не возвращаются.
+func (r *Repository) GetProfileByID(ctx context.Context, profileID int) (*models.UserProfile, error) {
+	var profile models.UserProfile
+
+	err := r.db.QueryRow(ctx,
+		`SELECT p.id, p.user_id, u.username, `+profileAgeColumn+`, p.avatar_url, p.about_me, p.gender, p.looking_for,
+            p.hidden_fields, `+profileInterestsColumn+`, `+profileLocationColumns+`, p.created_at, p.updated_at
+        FROM profiles p
+        JOIN users u ON p.user_id = u.id
+        WHERE p.id = $1 AND u.deleted_at IS NULL AND u.purged_at IS NULL`,
+		profileID).Scan(&profile.ID, &profile.UserID, &profile.Username, &profile.Age, &profile.AvatarUrl, &profile.AboutMe,
+		&profile.Gender, &profile.LookingFor, &profile.HiddenFields, &profile.Interests, &profile.City, &profile.Latitude,
+		&profile.Longitude, &profile.CreatedAt, &profile.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProfileNotFound
+		}
+		return nil, fmt.Errorf("failed to find profile: %w", err)
+	}
+
+	return &profile, nil
+}
+
+// feedSortColumns ключ сортировки ленты и направление для каждого варианта сортировки
+var feedSortColumns = map[string]struct {
+	column string
+	desc   bool
+}{
+	models.FeedSortRecent:  {"p.updated_at", true},
+	models.FeedSortNewest:  {"p.created_at", true},
+	models.FeedSortAgeAsc:  {"u.date_of_birth", true},
+	models.FeedSortAgeDesc: {"u.date_of_birth", false},
+}
+
+// GetProfiles возвращает страницу ленты анкет по фильтру (keyset-пагинация по ключу
+// сортировки и id) и курсор следующей страницы (nil, если страница последняя).
+// Возраст вычисляется по дате рождения на момент запроса.
+func (r *Repository) GetProfiles(ctx context.Context, filter models.ProfileFilter) ([]*models.UserProfile, *models.ProfileCursor, error) {
+	sort, ok := feedSortColumns[filter.Sort]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown profile sort %q", filter.Sort)
+	}
+
+	now := time.Now()
+
+	q := newFeedQuery(filter.ViewerID, filter.Origin)
+	if filter.Origin != nil && filter.WithinKm > 0 {
+		q.within(filter.WithinKm)
+	}
+	if filter.Mutual != nil {
+		q.mutual(filter.Mutual, now)
+	}
+
+	// Возраст не меньше MinAge: родился не позже, чем MinAge лет назад
+	if filter.MinAge > 0 {
+		q.add("u.date_of_birth <= ?", now.AddDate(-filter.MinAge, 0, 0))
+	}
+	// Возраст не больше MaxAge: родился позже, чем MaxAge+1 лет назад
+	if filter.MaxAge > 0 {
+		q.add("u.date_of_birth > ?", now.AddDate(-filter.MaxAge-1, 0, 0))
+	}
+	if filter.Gender != "" {
+		q.add("p.gender = ?", filter.Gender)
+	}
+	if filter.LookingFor != "" {
+		q.add("p.looking_for = ?", filter.LookingFor)
+	}
+
+	// Скрытое владельцем поле нельзя узнать подбором фильтров и сортировки
+	if filter.MinAge > 0 || filter.MaxAge > 0 || sort.column == "u.date_of_birth" {
+		q.add("NOT ('age' = ANY(p.hidden_fields))")
+	}
+	if filter.Gender != "" {
+		q.add("NOT ('gender' = ANY(p.hidden_fields))")
+	}
+	if filter.LookingFor != "" {
+		q.add("NOT ('looking_for' = ANY(p.hidden_fields))")
+	}
+	if len(filter.InterestIDs) > 0 {
+		q.add("NOT ('interests' = ANY(p.hidden_fields))")
+	}
+	// any - хотя бы один из интересов, all - все интересы (InterestIDs без повторов)
+	if len(filter.InterestIDs) > 0 {
+		if filter.MatchAllInterests {
+			q.add(`(SELECT count(*) FROM profile_interests pi
+                WHERE pi.profile_id = p.id AND pi.interest_id = ANY(?)) = ?`, filter.InterestIDs, len(filter.InterestIDs))
+		} else {
+			q.add(`EXISTS (SELECT 1 FROM profile_interests pi
+                WHERE pi.profile_id = p.id AND pi.interest_id = ANY(?))`, filter.InterestIDs)
+		}
+	}
+	if filter.ActiveSince != nil {
+		q.add("p.updated_at >= ?", *filter.ActiveSince)
+	}
+	// Без даты рождения (учетные записи из OIDC) анкету нельзя упорядочить по возрасту
+	if sort.column == "u.date_of_birth" {
+		q.add("u.date_of_birth IS NOT NULL")
+	}
+	if filter.After != nil {
+		comparison := ">"
+		if sort.desc {
+			comparison = "<"
+		}
+		q.add("("+sort.column+", p.id) "+comparison+" (?, ?)", filter.After.Key, filter.After.ID)
+	}
+
+	direction := "ASC"
+	if sort.desc {
+		direction = "DESC"
+	}
+
+	// Читаем на одну анкету больше, чтобы узнать, есть ли следующая страница
+	limit := q.param(filter.Limit + 1)
+	query := `SELECT p.id, p.user_id, u.username, ` + profileAgeColumn + `, p.avatar_url, p.about_me,
+            p.gender, p.looking_for, p.hidden_fields, ` + profileInterestsColumn + `, p.city, ` + q.distanceColumn() + `,
+            p.created_at, p.updated_at, ` + sort.column + `
+        FROM profiles p
+        JOIN users u ON p.user_id = u.id
+        LEFT JOIN discovery_preferences dp ON dp.user_id = p.user_id
+        WHERE ` + q.where() + `
+        ORDER BY ` + sort.column + " " + direction + ", p.id " + direction + `
+        LIMIT ` + limit
+
+	rows, err := r.db.Query(ctx, query, q.args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := []*models.UserProfile{}
+	var keys []time.Time
+
+	for rows.Next() {
+		var profile models.UserProfile
+		var key time.Time
+
+		err := rows.Scan(&profile.ID, &profile.UserID, &profile.Username, &profile.Age, &profile.AvatarUrl, &profile.AboutMe,
+			&profile.Gender, &profile.LookingFor, &profile.HiddenFields, &profile.Interests, &profile.City, &profile.DistanceKm,
+			&profile.CreatedAt, &profile.UpdatedAt, &key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan profile: %w", err)
+		}
+
+		profiles = append(profiles, &profile)
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	if len(profiles) <= filter.Limit {
+		return profiles, nil, nil
+	}
+
+	profiles = profiles[:filter.Limit]
+	last := profiles[len(profiles)-1]
+
+	return profiles, &models.ProfileCursor{
+		Sort: filter.Sort,
+		Key:  keys[len(profiles)-1],
+		ID:   last.ID,
+	}, nil
+}
+
+// GetRecommendationCandidates возвращает до limit недавно активных анкет, которые можно
+// рекомендовать пользователю viewerID: кроме его собственной, удаленных и тех, на которые
+// он уже откликался (в том числе получив отказ - это статус его отклика). Если задан match,
+// остаются только анкеты, взаимно подходящие по предпочтениям подбора.
+func (r *Repository) GetRecommendationCandidates(ctx context.Context, viewerID int, origin *models.GeoPoint,
+	match *models.MutualMatch, limit int) ([]*models.UserProfile, error) {
+	q := newFeedQuery(viewerID, origin)
+	q.add(`NOT EXISTS (
+                SELECT 1 FROM responses r
+                JOIN profiles viewer ON viewer.id = r.responder_id
+                WHERE viewer.user_id = ? AND r.profile_id = p.id
+            )`, viewerID)
+	if match != nil {
+		q.mutual(match, time.Now())
+	}
+
+	rows, err := r.db.Query(ctx,
+		`SELECT p.id, p.user_id, u.username, `+profileAgeColumn+`,
+            p.avatar_url, p.about_me, p.gender, p.looking_for, p.hidden_fields, `+profileInterestsColumn+`,
+            p.city, p.created_at, p.updated_at
+        FROM profiles p
+        JOIN users u ON p.user_id = u.id
+        LEFT JOIN discovery_preferences dp ON dp.user_id = p.user_id
+        WHERE `+q.where()+`
+        ORDER BY p.updated_at DESC, p.id DESC
+        LIMIT `+q.param(limit),
+		q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recommendation candidates: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := []*models.UserProfile{}
+	for rows.Next() {
+		var profile models.UserProfile
+
+		err := rows.Scan(&profile.ID, &profile.UserID, &profile.Username, &profile.Age, &profile.AvatarUrl, &profile.AboutMe,
+			&profile.Gender, &profile.LookingFor, &profile.HiddenFields, &profile.Interests, &profile.City,
+			&profile.CreatedAt, &profile.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan profile: %w", err)
+		}
+		profiles = append(profiles, &profile)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return profiles, nil
+}
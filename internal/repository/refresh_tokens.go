@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenExpired  = errors.New("refresh token expired")
+	ErrRefreshTokenRevoked  = errors.New("refresh token revoked")
+	ErrRefreshTokenReused   = errors.New("refresh token reused")
+)
+
+// CreateRefreshToken сохраняет хэш нового refresh-токена в указанном семействе
+func (r *Repository) CreateRefreshToken(ctx context.Context, userID int, familyID, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		"INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)",
+		userID, familyID, tokenHash, expiresAt, time.Now())
+
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// RotateRefreshToken помечает токен oldHash как использованный и выпускает
+// вместо него newHash в том же семействе. Если oldHash уже был ротирован,
+// всё семейство отзывается и возвращается ErrRefreshTokenReused.
+func (r *Repository) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (int, string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id int64
+	var userID int
+	var familyID string
+	var oldExpiresAt time.Time
+	var rotatedAt, revokedAt *time.Time
+
+	err = tx.QueryRow(ctx,
+		`SELECT id, user_id, family_id, expires_at, rotated_at, revoked_at
+        FROM refresh_tokens
+        WHERE token_hash = $1
+        FOR UPDATE`,
+		oldHash).Scan(&id, &userID, &familyID, &oldExpiresAt, &rotatedAt, &revokedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, "", ErrRefreshTokenNotFound
+		}
+		return 0, "", fmt.Errorf("failed to find refresh token: %w", err)
+	}
+
+	if revokedAt != nil {
+		return 0, "", ErrRefreshTokenRevoked
+	}
+
+	// Токен уже обменивался: скорее всего, он утёк, поэтому отзываем всё семейство
+	if rotatedAt != nil {
+		if _, err := tx.Exec(ctx,
+			"UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL",
+			time.Now(), familyID); err != nil {
+			return 0, "", fmt.Errorf("failed to revoke refresh token family: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx,
+			"UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL",
+			time.Now(), familyID); err != nil {
+			return 0, "", fmt.Errorf("failed to revoke session: %w", err)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return 0, "", fmt.Errorf("failed to commit transaction: %w", err)
+		}
+
+		return userID, familyID, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(oldExpiresAt) {
+		return 0, "", ErrRefreshTokenExpired
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE refresh_tokens SET rotated_at = $1 WHERE id = $2",
+		time.Now(), id); err != nil {
+		return 0, "", fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		"INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)",
+		userID, familyID, newHash, expiresAt, time.Now()); err != nil {
+		return 0, "", fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return userID, familyID, nil
+}
+
+// RevokeRefreshTokenFamily отзывает семейство, к которому относится токен tokenHash пользователя userID,
+// вместе с соответствующей сессией
+func (r *Repository) RevokeRefreshTokenFamily(ctx context.Context, userID int, tokenHash string) error {
+	_, err := r.db.Exec(ctx,
+		`WITH family AS (
+            SELECT family_id FROM refresh_tokens WHERE token_hash = $2 AND user_id = $3
+        ), revoked_sessions AS (
+            UPDATE sessions SET revoked_at = $1
+            WHERE revoked_at IS NULL AND id IN (SELECT family_id FROM family)
+        )
+        UPDATE refresh_tokens SET revoked_at = $1
+        WHERE revoked_at IS NULL
+            AND family_id IN (SELECT family_id FROM family)`,
+		time.Now(), tokenHash, userID)
+
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens отзывает все refresh-токены пользователя
+func (r *Repository) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+		time.Now(), userID)
+
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
@@ -2,15 +2,25 @@ package repository
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	models "passion-pals-backend/internal/models"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// uniqueViolation код ошибки Postgres при нарушении уникальности
+const uniqueViolation = "23505"
+
+var (
+	ErrUserExists   = errors.New("user with this username or email already exists")
+	ErrUserNotFound = errors.New("user not found")
+)
+
 type Repository struct {
 	db *pgxpool.Pool
 }
@@ -26,64 +36,148 @@ func NewRepository(connStr string) (*Repository, error) {
 	return &Repository{db: db}, nil
 }
 
-// CreateUser создает нового пользователя в базе данных
+// CreateUser создает нового пользователя и его профиль в базе данных
 func (r *Repository) CreateUser(ctx context.Context, username, password, email string, birth_date time.Time, gender string) (int, error) {
 	var userID int
 
-	err := r.db.QueryRow(ctx,
-		"INSERT INTO users (username, email, passoword, date_of_birth, gender) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		"INSERT INTO users (username, email, password, date_of_birth, gender) VALUES ($1, $2, $3, $4, $5) RETURNING id",
 		username, email, password, birth_date, gender).Scan(&userID)
 
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return 0, ErrUserExists
+		}
 		return 0, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	pErr := r.db.QueryRow(ctx,
-		"INSERT INTO profiles (user_id, gender, age, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)",
-		userID, gender, сalculateAge(birth_date), time.Now(), time.Now())
+	_, err = tx.Exec(ctx,
+		`INSERT INTO profiles (user_id, gender, age, created_at, updated_at)
+        VALUES ($1, $2, date_part('year', age($3::date))::int, $4, $5)`,
+		userID, gender, birth_date, time.Now(), time.Now())
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to create profile: %w", err)
+	}
 
-	if pErr != nil {
-		return userID, fmt.Errorf("failed to create profile: %w", err)
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return userID, nil
 }
 
-func сalculateAge(birthDate time.Time) int {
-	now := time.Now()
+// UserExists проверяет, заняты ли имя пользователя и email (без учета регистра,
+// как и при поиске по email)
+func (r *Repository) UserExists(ctx context.Context, username, email string) (bool, bool, error) {
+	var usernameTaken, emailTaken bool
+
+	err := r.db.QueryRow(ctx,
+		`SELECT
+            EXISTS (SELECT 1 FROM users WHERE lower(username) = lower($1)),
+            EXISTS (SELECT 1 FROM users WHERE lower(email) = lower($2))`,
+		username, email).Scan(&usernameTaken, &emailTaken)
+
+	if err != nil {
+		return false, false, fmt.Errorf("failed to check user existence: %w", err)
+	}
+
+	return usernameTaken, emailTaken, nil
+}
+
+// FindUserByUserEmail ищет пользователя по email без учета регистра
+func (r *Repository) FindUserByUserEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
 
-	// Вычисляем разницу в годах
-	age := now.Year() - birthDate.Year()
+	err := r.db.QueryRow(ctx,
+		`SELECT id, username, email, password, email_verified_at, totp_enabled_at IS NOT NULL, roles, deleted_at
+        FROM users
+        WHERE lower(email) = lower($1) AND purged_at IS NULL`,
+		email).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.EmailVerifiedAt, &user.TOTPEnabled, &user.Roles, &user.DeletedAt)
 
-	// Проверяем, был ли уже день рождения в этом году
-	if now.YearDay() < birthDate.YearDay() {
-		age-- // Если день рождения ещё не наступил, уменьшаем возраст на 1
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
 
-	return age
+	return &user, nil
 }
 
-// FindUserByUsername ищет пользователя по имени пользователя
-func (r *Repository) FindUserByUserEmail(ctx context.Context, email string) (int, string, error) {
-	var id int
-	var password string
+// FindUserByID ищет пользователя по id
+func (r *Repository) FindUserByID(ctx context.Context, userID int) (*models.User, error) {
+	var user models.User
 
 	err := r.db.QueryRow(ctx,
-		"SELECT id, password FROM users WHERE email = $1",
-		email).Scan(&id, &password)
+		`SELECT id, username, email, password, email_verified_at, totp_enabled_at IS NOT NULL, roles, deleted_at
+        FROM users
+        WHERE id = $1 AND purged_at IS NULL`,
+		userID).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.EmailVerifiedAt, &user.TOTPEnabled, &user.Roles, &user.DeletedAt)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return 0, "", fmt.Errorf("user not found")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
 		}
-		return 0, "", fmt.Errorf("failed to find user: %w", err)
+		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
 
-	return id, password, nil
+	return &user, nil
+}
+
+// GetUserRoles возвращает роли пользователя
+func (r *Repository) GetUserRoles(ctx context.Context, userID int) ([]string, error) {
+	var roles []string
+
+	err := r.db.QueryRow(ctx, "SELECT roles FROM users WHERE id = $1", userID).Scan(&roles)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// SetUserRoles заменяет роли пользователя. Возвращает false, если пользователь не найден.
+func (r *Repository) SetUserRoles(ctx context.Context, userID int, roles []string) (bool, error) {
+	tag, err := r.db.Exec(ctx, "UPDATE users SET roles = $1 WHERE id = $2", roles, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to set user roles: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// UpdatePassword заменяет хэш пароля, если он не изменился с момента чтения (currentHash).
+// Возвращает false, если пароль успели сменить параллельно.
+func (r *Repository) UpdatePassword(ctx context.Context, userID int, currentHash, newHash string) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		"UPDATE users SET password = $1 WHERE id = $2 AND password = $3",
+		newHash, userID, currentHash)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to update password: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
 }
 
 // GetProfileByUserId возвращает данные профиля пользователя по id
 func (r *Repository) GetProfileByUserId(ctx context.Context, userId int) (*models.UserProfile, error) {
+	var profileID int
+	var hiddenFields []string
+	var interests []string
+	var city string
+	var latitude, longitude *float64
 	var username string
 	var age int
 	var avatarUrl string
@@ -95,12 +189,16 @@ func (r *Repository) GetProfileByUserId(ctx context.Context, userId int) (*model
 
 	err := r.db.QueryRow(ctx,
 		`SELECT 
+            p.id,
             u.username, 
-            p.age, 
+            `+profileAgeColumn+`,
             p.avatar_url, 
             p.about_me, 
 			p.gender,
             p.looking_for, 
+            p.hidden_fields,
+            `+profileInterestsColumn+`,
+            `+profileLocationColumns+`,
             p.created_at, 
             p.updated_at 
         FROM 
@@ -109,112 +207,38 @@ func (r *Repository) GetProfileByUserId(ctx context.Context, userId int) (*model
             users u ON p.user_id = u.id
         WHERE 
             p.user_id = $1`,
-		userId).Scan(&username, &age, &avatarUrl, &aboutMe, &gender, &lookingFor, &createdAt, &updatedAt)
+		userId).Scan(&profileID, &username, &age, &avatarUrl, &aboutMe, &gender, &lookingFor, &hiddenFields, &interests,
+		&city, &latitude, &longitude, &createdAt, &updatedAt)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProfileNotFound
 		}
-		return nil, fmt.Errorf("failed to find user: %w", err)
+		return nil, fmt.Errorf("failed to find profile: %w", err)
 	}
 
 	return &models.UserProfile{
-		Username:   username,
-		Age:        age,
-		AvatarUrl:  avatarUrl,
-		AboutMe:    aboutMe,
-		Gender:     gender,
-		LookingFor: lookingFor,
-		CreatedAt:  createdAt,
-		UpdatedAt:  updatedAt,
+		ID:           profileID,
+		UserID:       userId,
+		Username:     username,
+		Age:          age,
+		AvatarUrl:    avatarUrl,
+		AboutMe:      aboutMe,
+		Gender:       gender,
+		LookingFor:   lookingFor,
+		HiddenFields: hiddenFields,
+		Interests:    interests,
+		City:         city,
+		Latitude:     latitude,
+		Longitude:    longitude,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
 	}, nil
 }
 
-// GetProfiles список актуальных анкет
-func (r *Repository) GetProfiles(ctx context.Context) ([]*models.UserProfile, error) {
-	// Определяем временную границу для последних 7 дней
-	sevenDaysAgo := time.Now().Add(-7 * 24 * time.Hour)
-
-	// Выполняем запрос к базе данных
-	rows, err := r.db.Query(ctx,
-		`SELECT 
-            u.username, 
-            p.age, 
-            p.avatar_url, 
-            p.about_me, 
-			p.gender,
-            p.looking_for, 
-            p.created_at, 
-            p.updated_at 
-        FROM 
-            profiles p
-        JOIN 
-            users u ON p.user_id = u.id
-        WHERE 
-            p.updated_at >= $1`,
-		sevenDaysAgo)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query profiles: %w", err)
-	}
-	defer rows.Close()
-
-	var profiles []*models.UserProfile
-
-	// Итерируем по результатам запроса
-	for rows.Next() {
-		var username string
-		var age int
-		var avatarUrl string
-		var aboutMe string
-		var gender string
-		var lookingFor string
-		var createdAt time.Time
-		var updatedAt time.Time
-
-		err := rows.Scan(&username, &age, &avatarUrl, &aboutMe, &gender, &lookingFor, &createdAt, &updatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan profile: %w", err)
-		}
-
-		profiles = append(profiles, &models.UserProfile{
-			Username:   username,
-			Age:        age,
-			AvatarUrl:  avatarUrl,
-			AboutMe:    aboutMe,
-			Gender:     gender,
-			LookingFor: lookingFor,
-			CreatedAt:  createdAt,
-			UpdatedAt:  updatedAt,
-		})
-	}
-
-	// Проверяем, были ли ошибки при итерации
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over rows: %w", err)
-	}
-
-	return profiles, nil
-}
-
-func (r *Repository) DeleteUserByID(ctx context.Context, userId int) error {
-
-	_, err := r.db.Exec(ctx, `
-    DELETE FROM users
-    WHERE id = $1;`, userId)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("user not found")
-		}
-		return fmt.Errorf("failed to find user: %w", err)
-	}
-
-	return nil
-}
-
 func (r *Repository) AddResponse(ctx context.Context, userId, profileId string) error {
 
-	err := r.db.QueryRow(ctx,
+	_, err := r.db.Exec(ctx,
 		"INSERT INTO responses (profile_id, responder_id, status, created_At) VALUES ($1, $2, $3, $4)",
 		profileId, userId, "ожидание", time.Now())
 
@@ -251,12 +275,14 @@ func (r *Repository) RejectResponse(ctx context.Context, responseId string) erro
 	return nil
 }
 
+// GetIncomingResponses отклики на анкету пользователя userId вместе с анкетами откликнувшихся.
+// В responses хранятся id анкет, поэтому анкета пользователя находится по profiles.user_id.
 func (r *Repository) GetIncomingResponses(ctx context.Context, userId int) ([]*models.UserResponse, error) {
 
 	rows, err := r.db.Query(ctx,
 		`SELECT 
             r.status, 
-			u.username
+			u.username,
             p.age, 
             p.avatar_url, 
             p.about_me, 
@@ -266,12 +292,14 @@ func (r *Repository) GetIncomingResponses(ctx context.Context, userId int) ([]*m
             p.updated_at 
         FROM 
             responses r
+        JOIN 
+            profiles own ON own.id = r.profile_id
         JOIN 
             profiles p ON p.id = r.responder_id
 		JOIN 
             users u ON p.user_id = u.id
         WHERE 
-            r.responder_id = $1`,
+            own.user_id = $1`,
 		userId)
 
 	if err != nil {
@@ -315,12 +343,13 @@ func (r *Repository) GetIncomingResponses(ctx context.Context, userId int) ([]*m
 	return incomingResponses, nil
 }
 
+// GetOutgoingResponses отклики пользователя userId вместе с анкетами, на которые он откликнулся
 func (r *Repository) GetOutgoingResponses(ctx context.Context, userId int) ([]*models.UserResponse, error) {
 
 	rows, err := r.db.Query(ctx,
 		`SELECT 
             r.status, 
-			u.username
+			u.username,
             p.age, 
             p.avatar_url, 
             p.about_me, 
@@ -331,11 +360,13 @@ func (r *Repository) GetOutgoingResponses(ctx context.Context, userId int) ([]*m
         FROM 
             responses r
         JOIN 
-            profiles p ON p.id = r.responder_id
+            profiles own ON own.id = r.responder_id
+        JOIN 
+            profiles p ON p.id = r.profile_id
 		JOIN 
             users u ON p.user_id = u.id
         WHERE 
-            r.profile_id = $1`,
+            own.user_id = $1`,
 		userId)
 
 	if err != nil {
@@ -415,8 +446,8 @@ func (r *Repository) GetNotifications(ctx context.Context, userId int) ([]*model
 
 func (r *Repository) AddNotification(ctx context.Context, userId int, message string, notificationType models.NotificationType) error {
 
-	err := r.db.QueryRow(ctx,
-		"INSERT INTO notifications (user_id, message, is_read, created_at, type) VALUES ($1, $2, $3, $4)",
+	_, err := r.db.Exec(ctx,
+		"INSERT INTO notifications (user_id, message, is_read, created_at, type) VALUES ($1, $2, $3, $4, $5)",
 		userId, message, false, time.Now(), notificationType.ToInt())
 
 	if err != nil {
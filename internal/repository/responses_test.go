@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	models "passion-pals-backend/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testRepository репозиторий на отдельной схеме базы TEST_DATABASE_URL; без нее тест пропускается.
+// Схема удаляется после теста.
+func testRepository(t *testing.T, ddl ...string) *Repository {
+	t.Helper()
+
+	connStr := os.Getenv("TEST_DATABASE_URL")
+	if connStr == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+
+	cfg, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+
+	db, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		db.Close()
+	})
+
+	if _, err := db.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range ddl {
+		if _, err := db.Exec(ctx, query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &Repository{db: db}
+}
+
+// Отклики хранят id анкет, а методы принимают id пользователя. id анкет нарочно не совпадают
+// с id пользователей, чтобы путаница между ними не проходила незамеченной.
+func TestResponsesDirection(t *testing.T) {
+	r := testRepository(t,
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, username TEXT NOT NULL)`,
+		`CREATE TABLE profiles (
+            id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users (id),
+            age INTEGER NOT NULL DEFAULT 0, avatar_url TEXT NOT NULL DEFAULT '', about_me TEXT NOT NULL DEFAULT '',
+            gender TEXT NOT NULL DEFAULT '', looking_for TEXT NOT NULL DEFAULT '',
+            created_at TIMESTAMPTZ NOT NULL DEFAULT now(), updated_at TIMESTAMPTZ NOT NULL DEFAULT now())`,
+		`CREATE TABLE responses (
+            id SERIAL PRIMARY KEY, profile_id INTEGER NOT NULL, responder_id INTEGER NOT NULL,
+            status TEXT NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT now())`,
+		`INSERT INTO users (id, username) VALUES (1, 'alice'), (2, 'bob'), (3, 'carol')`,
+		`INSERT INTO profiles (id, user_id) VALUES (101, 1), (102, 2), (103, 3)`,
+		// alice откликнулась на bob, carol - на alice
+		`INSERT INTO responses (profile_id, responder_id, status) VALUES (102, 101, 'ожидание'), (101, 103, 'ожидание')`,
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		userID   int
+		incoming []string
+		outgoing []string
+	}{
+		{"alice", 1, []string{"carol"}, []string{"bob"}},
+		{"bob", 2, []string{"alice"}, nil},
+		{"carol", 3, nil, []string{"alice"}},
+		{"profile id is not a user id", 101, nil, nil},
+	}
+
+	usernames := func(responses []*models.UserResponse) []string {
+		var names []string
+		for _, response := range responses {
+			names = append(names, response.Responder.Username)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incoming, err := r.GetIncomingResponses(ctx, tt.userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := usernames(incoming); !slices.Equal(got, tt.incoming) {
+				t.Errorf("incoming = %v, want %v", got, tt.incoming)
+			}
+
+			outgoing, err := r.GetOutgoingResponses(ctx, tt.userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := usernames(outgoing); !slices.Equal(got, tt.outgoing) {
+				t.Errorf("outgoing = %v, want %v", got, tt.outgoing)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	models "passion-pals-backend/internal/models"
+)
+
+// RevokeToken добавляет access-токен в список отозванных
+func (r *Repository) RevokeToken(ctx context.Context, jti string, userID int, expiresAt, revokedAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at) VALUES ($1, $2, $3, $4)
+        ON CONFLICT (jti) DO NOTHING`,
+		jti, userID, expiresAt, revokedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserTokens отзывает все access-токены пользователя, выпущенные до revokedBefore
+func (r *Repository) RevokeUserTokens(ctx context.Context, userID int, revokedBefore, expiresAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO user_token_revocations (user_id, revoked_before, expires_at, revoked_at) VALUES ($1, $2, $3, $2)
+        ON CONFLICT (user_id) DO UPDATE SET
+            revoked_before = EXCLUDED.revoked_before,
+            expires_at = EXCLUDED.expires_at,
+            revoked_at = EXCLUDED.revoked_at`,
+		userID, revokedBefore, expiresAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// GetRevokedTokensSince возвращает неистекшие токены, отозванные после since
+func (r *Repository) GetRevokedTokensSince(ctx context.Context, since time.Time) ([]*models.RevokedToken, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT jti, user_id, expires_at, revoked_at FROM revoked_tokens WHERE revoked_at > $1 AND expires_at > $2",
+		since, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revoked tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.RevokedToken
+
+	for rows.Next() {
+		var token models.RevokedToken
+
+		if err := rows.Scan(&token.JTI, &token.UserID, &token.ExpiresAt, &token.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan revoked token: %w", err)
+		}
+
+		tokens = append(tokens, &token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// GetUserTokenRevocationsSince возвращает неистекшие отзывы "со всех устройств", сделанные после since
+func (r *Repository) GetUserTokenRevocationsSince(ctx context.Context, since time.Time) ([]*models.UserTokenRevocation, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT user_id, revoked_before, expires_at, revoked_at FROM user_token_revocations WHERE revoked_at > $1 AND expires_at > $2",
+		since, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user token revocations: %w", err)
+	}
+	defer rows.Close()
+
+	var revocations []*models.UserTokenRevocation
+
+	for rows.Next() {
+		var revocation models.UserTokenRevocation
+
+		if err := rows.Scan(&revocation.UserID, &revocation.RevokedBefore, &revocation.ExpiresAt, &revocation.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user token revocation: %w", err)
+		}
+
+		revocations = append(revocations, &revocation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return revocations, nil
+}
+
+// PurgeExpiredRevocations удаляет записи об отзыве и погашении токенов, срок действия которых уже истек
+func (r *Repository) PurgeExpiredRevocations(ctx context.Context, now time.Time) (int64, error) {
+	tokens, err := r.db.Exec(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge revoked tokens: %w", err)
+	}
+
+	users, err := r.db.Exec(ctx, "DELETE FROM user_token_revocations WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge user token revocations: %w", err)
+	}
+
+	challenges, err := r.db.Exec(ctx, "DELETE FROM used_mfa_challenges WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge used mfa challenges: %w", err)
+	}
+
+	return tokens.RowsAffected() + users.RowsAffected() + challenges.RowsAffected(), nil
+}
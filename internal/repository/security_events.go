@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	models "passion-pals-backend/internal/models"
+	"strconv"
+	"strings"
+)
+
+// CreateSecurityEvent добавляет запись в журнал событий безопасности
+func (r *Repository) CreateSecurityEvent(ctx context.Context, event *models.SecurityEvent) error {
+	details := event.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO security_events (user_id, actor_id, type, ip, user_agent, request_id, details, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id`,
+		event.UserID, event.ActorID, event.Type, event.IP, event.UserAgent, event.RequestID, details, event.CreatedAt).Scan(&event.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create security event: %w", err)
+	}
+
+	return nil
+}
+
+// GetSecurityEvents возвращает события журнала по фильтру, от новых к старым
+func (r *Repository) GetSecurityEvents(ctx context.Context, filter models.SecurityEventFilter) ([]models.SecurityEvent, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.UserID != 0 {
+		addCondition("user_id = ?", filter.UserID)
+	}
+	if filter.Type != "" {
+		addCondition("type = ?", filter.Type)
+	}
+	if filter.IP != "" {
+		addCondition("ip = ?", filter.IP)
+	}
+	if filter.Since != nil {
+		addCondition("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		addCondition("created_at < ?", *filter.Until)
+	}
+	if filter.BeforeID != 0 {
+		addCondition("id < ?", filter.BeforeID)
+	}
+
+	query := `SELECT id, user_id, actor_id, type, ip, user_agent, request_id, details, created_at
+        FROM security_events`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit)
+	query += " ORDER BY id DESC LIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query security events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.SecurityEvent{}
+	for rows.Next() {
+		var event models.SecurityEvent
+		if err := rows.Scan(&event.ID, &event.UserID, &event.ActorID, &event.Type, &event.IP, &event.UserAgent,
+			&event.RequestID, &event.Details, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan security event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate security events: %w", err)
+	}
+
+	return events, nil
+}
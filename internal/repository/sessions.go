@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	models "passion-pals-backend/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// CreateSession сохраняет новую сессию пользователя
+func (r *Repository) CreateSession(ctx context.Context, session models.Session) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO sessions (id, user_id, current_jti, user_agent, ip, created_at, last_seen_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		session.ID, session.UserID, session.CurrentJTI, session.UserAgent, session.IP,
+		session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSessionToken привязывает сессию к новому access-токену после обновления пары токенов
+func (r *Repository) UpdateSessionToken(ctx context.Context, sessionID, jti, userAgent, ip string, expiresAt time.Time) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE sessions
+        SET current_jti = $1, user_agent = $2, ip = $3, last_seen_at = $4, expires_at = $5
+        WHERE id = $6 AND revoked_at IS NULL`,
+		jti, userAgent, ip, time.Now(), expiresAt, sessionID)
+
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// TouchSession обновляет время последней активности. Возвращает false, если сессия отозвана или истекла.
+func (r *Repository) TouchSession(ctx context.Context, sessionID string, seenAt time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE sessions SET last_seen_at = $1
+        WHERE id = $2 AND revoked_at IS NULL AND expires_at > $1`,
+		seenAt, sessionID)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetUserSessions возвращает активные сессии пользователя, начиная с последней активной
+func (r *Repository) GetUserSessions(ctx context.Context, userID int) ([]models.Session, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT id, user_id, current_jti, user_agent, ip, created_at, last_seen_at, expires_at
+        FROM sessions
+        WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+        ORDER BY last_seen_at DESC`,
+		userID, time.Now())
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(&session.ID, &session.UserID, &session.CurrentJTI, &session.UserAgent, &session.IP,
+			&session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession отзывает сессию пользователя вместе с ее refresh-токенами.
+// Возвращает jti последнего выданного в ней access-токена.
+func (r *Repository) RevokeSession(ctx context.Context, userID int, sessionID string) (string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+
+	var jti string
+	err = tx.QueryRow(ctx,
+		`UPDATE sessions SET revoked_at = $1
+        WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL
+        RETURNING current_jti`,
+		now, sessionID, userID).Scan(&jti)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrSessionNotFound
+		}
+		return "", fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL",
+		now, sessionID); err != nil {
+		return "", fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return jti, nil
+}
+
+// RevokeUserSessions отзывает все сессии пользователя
+func (r *Repository) RevokeUserSessions(ctx context.Context, userID int) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+		time.Now(), userID)
+
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeSessions удаляет сессии, истекшие или отозванные до before
+func (r *Repository) PurgeSessions(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx,
+		"DELETE FROM sessions WHERE expires_at < $1 OR revoked_at < $1",
+		before)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge sessions: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	models "passion-pals-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFAChallengeUsed   = errors.New("mfa challenge is already used")
+)
+
+// UseMFAChallenge погашает промежуточный токен входа с 2FA. Повторное предъявление
+// того же токена возвращает ErrMFAChallengeUsed.
+func (r *Repository) UseMFAChallenge(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	tag, err := r.db.Exec(ctx,
+		`INSERT INTO used_mfa_challenges (jti, user_id, expires_at) VALUES ($1, $2, $3)
+        ON CONFLICT (jti) DO NOTHING`,
+		jti, userID, expiresAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to use mfa challenge: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrMFAChallengeUsed
+	}
+
+	return nil
+}
+
+// GetTOTPState возвращает состояние 2FA пользователя
+func (r *Repository) GetTOTPState(ctx context.Context, userID int) (*models.TOTPState, error) {
+	var secret *string
+	var state models.TOTPState
+
+	err := r.db.QueryRow(ctx,
+		"SELECT totp_secret, totp_enabled_at, totp_last_step FROM users WHERE id = $1",
+		userID).Scan(&secret, &state.EnabledAt, &state.LastStep)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to get totp state: %w", err)
+	}
+
+	if secret != nil {
+		state.Secret = *secret
+	}
+
+	return &state, nil
+}
+
+// SetPendingTOTPSecret сохраняет новый секрет, пока 2FA еще не включена
+func (r *Repository) SetPendingTOTPSecret(ctx context.Context, userID int, secret string) error {
+	tag, err := r.db.Exec(ctx,
+		"UPDATE users SET totp_secret = $1, totp_last_step = NULL WHERE id = $2 AND totp_enabled_at IS NULL",
+		secret, userID)
+
+	if err != nil {
+		return fmt.Errorf("failed to set totp secret: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrTOTPAlreadyEnabled
+	}
+
+	return nil
+}
+
+// EnableTOTP включает 2FA и заменяет коды восстановления на новые
+func (r *Repository) EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		"UPDATE users SET totp_enabled_at = $1, totp_last_step = $2 WHERE id = $3 AND totp_enabled_at IS NULL",
+		time.Now(), step, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable totp: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTOTPAlreadyEnabled
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DisableTOTP выключает 2FA и удаляет секрет и коды восстановления
+func (r *Repository) DisableTOTP(ctx context.Context, userID int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = $1",
+		userID); err != nil {
+		return fmt.Errorf("failed to disable totp: %w", err)
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep фиксирует принятый временной шаг. Возвращает false, если код
+// этого или более позднего шага уже был использован.
+func (r *Repository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		"UPDATE users SET totp_last_step = $1 WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)",
+		step, userID)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to use totp step: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// UseRecoveryCode погашает код восстановления. Возвращает false, если код не найден или уже использован.
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		"UPDATE user_recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL",
+		time.Now(), userID, codeHash)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM user_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	for _, codeHash := range codeHashes {
+		if _, err := tx.Exec(ctx,
+			"INSERT INTO user_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, $3)",
+			userID, codeHash, time.Now()); err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
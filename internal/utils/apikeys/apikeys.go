@@ -0,0 +1,123 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/middleware"
+	"passion-pals-backend/internal/utils/rbac"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// keyPrefix отличает API-ключи от других секретов (например, при поиске утечек в коде)
+	keyPrefix = "pp_"
+	// displayPrefixLength сколько символов ключа хранится открыто для отображения в списке
+	displayPrefixLength = 8
+)
+
+// Store проверяет API-ключи и отмечает их использование. Время последнего
+// использования пишется в базу не чаще раза в touchInterval на ключ.
+type Store struct {
+	log           *slog.Logger
+	repo          *repository.Repository
+	touchInterval time.Duration
+
+	mu      sync.Mutex
+	touched map[int64]time.Time
+}
+
+func New(log *slog.Logger, repo *repository.Repository, touchInterval time.Duration) *Store {
+	return &Store{
+		log:           log,
+		repo:          repo,
+		touchInterval: touchInterval,
+		touched:       make(map[int64]time.Time),
+	}
+}
+
+// Generate создает новый ключ. Возвращает сам ключ (показывается один раз),
+// открытый префикс для отображения и хэш для хранения.
+func Generate() (string, string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return key, key[:len(keyPrefix)+displayPrefixLength], Hash(key), nil
+}
+
+// Hash возвращает SHA-256 хэш ключа в hex
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate проверяет ключ и возвращает claims его владельца. Области доступа
+// ключа ограничиваются текущими ролями владельца. ok == false - ключ недействителен.
+func (s *Store) Authenticate(ctx context.Context, key, ip string) (*middleware.Claims, bool, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, false, nil
+	}
+
+	apiKey, err := s.repo.FindAPIKeyByHash(ctx, Hash(key))
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	allowed := rbac.ScopesForRoles(apiKey.Roles)
+	scopes := []string{}
+	for _, scope := range apiKey.Scopes {
+		if slices.Contains(allowed, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	s.touch(ctx, apiKey.ID, ip)
+
+	return &middleware.Claims{
+		UserID:   apiKey.UserID,
+		Roles:    apiKey.Roles,
+		Scopes:   scopes,
+		APIKeyID: apiKey.ID,
+	}, true, nil
+}
+
+// Forget сбрасывает отметку использования ключа (после отзыва)
+func (s *Store) Forget(keyID int64) {
+	s.mu.Lock()
+	delete(s.touched, keyID)
+	s.mu.Unlock()
+}
+
+// touch обновляет last_used_at, если с прошлой записи прошло не меньше touchInterval
+func (s *Store) touch(ctx context.Context, keyID int64, ip string) {
+	now := time.Now()
+
+	s.mu.Lock()
+	last, ok := s.touched[keyID]
+	if ok && now.Sub(last) < s.touchInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.touched[keyID] = now
+	s.mu.Unlock()
+
+	if err := s.repo.TouchAPIKey(ctx, keyID, now, ip); err != nil {
+		s.log.Error("failed to touch api key", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	models "passion-pals-backend/internal/models"
+	"passion-pals-backend/internal/repository"
+	"passion-pals-backend/internal/utils/middleware"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Типы событий журнала безопасности
+const (
+	EventRegistered      = "registered"
+	EventLoginSucceeded  = "login_succeeded"
+	EventLoginFailed     = "login_failed"
+	EventPasswordChanged = "password_changed"
+	EventPasswordReset   = "password_reset"
+	EventTokensRevoked   = "tokens_revoked"
+	EventRolesChanged    = "roles_changed"
+	EventAccountDeleted  = "account_deleted"
+	EventAccountRestored = "account_restored"
+)
+
+// maxUserAgentLength ограничение длины User-Agent в журнале
+const maxUserAgentLength = 512
+
+// Recorder записывает события безопасности вместе с IP, User-Agent и идентификатором запроса
+type Recorder struct {
+	log  *slog.Logger
+	repo *repository.Repository
+}
+
+func New(log *slog.Logger, repo *repository.Repository) *Recorder {
+	return &Recorder{
+		log:  log,
+		repo: repo,
+	}
+}
+
+// EmailHash хэш адреса для журнала: по нему можно сопоставить события одного адреса
+// и обезличить их при удалении учетной записи, не храня сам адрес
+func EmailHash(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// Record записывает событие eventType учетной записи userID (0 - пользователь неизвестен).
+// Ошибка записи только логируется: журнал не должен ломать вход и другие операции.
+func (r *Recorder) Record(c *gin.Context, eventType string, userID int, details map[string]any) {
+	r.record(c, eventType, userID, 0, details)
+}
+
+// RecordByActor записывает событие, которое пользователь actorID (например, администратор)
+// выполнил над учетной записью userID
+func (r *Recorder) RecordByActor(c *gin.Context, eventType string, userID, actorID int, details map[string]any) {
+	r.record(c, eventType, userID, actorID, details)
+}
+
+func (r *Recorder) record(c *gin.Context, eventType string, userID, actorID int, details map[string]any) {
+	const op = "audit.Record"
+
+	ua := c.Request.UserAgent()
+	if len(ua) > maxUserAgentLength {
+		ua = ua[:maxUserAgentLength]
+	}
+
+	event := &models.SecurityEvent{
+		Type:      eventType,
+		IP:        c.ClientIP(),
+		UserAgent: ua,
+		RequestID: middleware.RequestIDFromContext(c),
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+	if userID != 0 {
+		event.UserID = &userID
+	}
+	if actorID != 0 {
+		event.ActorID = &actorID
+	}
+
+	if err := r.repo.CreateSecurityEvent(c.Request.Context(), event); err != nil {
+		r.log.Error("failed to record security event",
+			slog.String("op", op),
+			slog.String("type", eventType),
+			slog.String("request_id", event.RequestID),
+			slog.String("error", err.Error()))
+	}
+}
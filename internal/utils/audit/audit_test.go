@@ -0,0 +1,18 @@
+package audit
+
+import "testing"
+
+func TestEmailHash(t *testing.T) {
+	// sha256("user@example.com")
+	const want = "b4c9a289323b21a01c3e940f150eb9b8c542587f1abfd8f0e1cc1ffc5e475514"
+
+	for _, email := range []string{"user@example.com", "User@Example.COM", "  user@example.com\n"} {
+		if got := EmailHash(email); got != want {
+			t.Errorf("EmailHash(%q) = %s, want %s", email, got, want)
+		}
+	}
+
+	if EmailHash("other@example.com") == want {
+		t.Error("different addresses have the same hash")
+	}
+}
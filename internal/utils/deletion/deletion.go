@@ -0,0 +1,69 @@
+package deletion
+
+import (
+	"context"
+	"log/slog"
+	"passion-pals-backend/internal/config"
+	"passion-pals-backend/internal/repository"
+	"time"
+)
+
+// Purger окончательно удаляет данные учетных записей, срок восстановления которых истек
+type Purger struct {
+	log  *slog.Logger
+	repo *repository.Repository
+	cfg  config.AccountDeletionConfig
+}
+
+func New(log *slog.Logger, repo *repository.Repository, cfg config.AccountDeletionConfig) *Purger {
+	return &Purger{
+		log:  log,
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+// PurgeAt время, после которого данные учетной записи, удаленной в deletedAt, будут удалены
+func (p *Purger) PurgeAt(deletedAt time.Time) time.Time {
+	return deletedAt.Add(p.cfg.GracePeriod)
+}
+
+// Run периодически удаляет данные до отмены ctx
+func (p *Purger) Run(ctx context.Context) {
+	const op = "deletion.Run"
+
+	log := p.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(p.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.purge(ctx, log)
+		}
+	}
+}
+
+// purge обрабатывает учетные записи пачками, пока они не закончатся
+func (p *Purger) purge(ctx context.Context, log *slog.Logger) {
+	var total int64
+
+	for ctx.Err() == nil {
+		purged, err := p.repo.PurgeDeletedUsers(ctx, time.Now().Add(-p.cfg.GracePeriod))
+		if err != nil {
+			log.Error("failed to purge deleted users", slog.String("error", err.Error()))
+			return
+		}
+		if purged == 0 {
+			break
+		}
+		total += purged
+	}
+
+	if total > 0 {
+		log.Info("deleted users purged", slog.Int64("count", total))
+	}
+}
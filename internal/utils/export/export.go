@@ -0,0 +1,246 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"passion-pals-backend/internal/config"
+	models "passion-pals-backend/internal/models"
+	"passion-pals-backend/internal/repository"
+	"path/filepath"
+	"time"
+)
+
+// Exporter собирает архивы с персональными данными в фоне. Очередь хранится
+// в таблице data_exports, поэтому выгрузки переживают перезапуск, а несколько
+// инстансов не возьмут одну выгрузку дважды.
+type Exporter struct {
+	log  *slog.Logger
+	repo *repository.Repository
+	cfg  config.ExportConfig
+	wake chan struct{}
+}
+
+func New(log *slog.Logger, repo *repository.Repository, cfg config.ExportConfig) (*Exporter, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("export: failed to create directory: %w", err)
+	}
+
+	return &Exporter{
+		log:  log,
+		repo: repo,
+		cfg:  cfg,
+		wake: make(chan struct{}, 1),
+	}, nil
+}
+
+// Enqueue будит обработчик, не дожидаясь очередного опроса очереди
+func (e *Exporter) Enqueue() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run обрабатывает очередь и удаляет истекшие архивы до отмены ctx
+func (e *Exporter) Run(ctx context.Context) {
+	const op = "export.Run"
+
+	log := e.log.With(slog.String("op", op))
+
+	pollTicker := time.NewTicker(e.cfg.PollInterval)
+	defer pollTicker.Stop()
+
+	purgeTicker := time.NewTicker(e.cfg.PurgeInterval)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.wake:
+			e.process(ctx, log)
+		case <-pollTicker.C:
+			e.process(ctx, log)
+		case <-purgeTicker.C:
+			e.purge(ctx, log)
+		}
+	}
+}
+
+// process берет выгрузки из очереди, пока она не опустеет
+func (e *Exporter) process(ctx context.Context, log *slog.Logger) {
+	for ctx.Err() == nil {
+		export, err := e.repo.ClaimDataExport(ctx, e.cfg.StaleAfter)
+		if errors.Is(err, repository.ErrDataExportNotFound) {
+			return
+		}
+		if err != nil {
+			log.Error("failed to claim data export", slog.String("error", err.Error()))
+			return
+		}
+
+		path, size, err := e.build(ctx, export)
+		expiresAt := time.Now().Add(e.cfg.TTL)
+
+		if err != nil {
+			log.Error("failed to build data export",
+				slog.String("export_id", export.ID), slog.String("error", err.Error()))
+			if err := e.repo.FailDataExport(ctx, export.ID, "Failed to build archive", expiresAt); err != nil {
+				log.Error("failed to mark data export failed", slog.String("error", err.Error()))
+			}
+			continue
+		}
+
+		if err := e.repo.CompleteDataExport(ctx, export.ID, path, size, expiresAt); err != nil {
+			log.Error("failed to complete data export", slog.String("error", err.Error()))
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// build собирает ZIP с JSON-файлами и возвращает путь к нему и размер
+func (e *Exporter) build(ctx context.Context, export *models.DataExport) (string, int64, error) {
+	files, err := e.collect(ctx, export.UserID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы не отдать недописанный архив
+	tmp, err := os.CreateTemp(e.cfg.Dir, export.ID+"-*.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	archive := zip.NewWriter(tmp)
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			tmp.Close()
+			return "", 0, fmt.Errorf("failed to add %s to archive: %w", file.name, err)
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			tmp.Close()
+			return "", 0, fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		tmp.Close()
+		return "", 0, fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		return "", 0, fmt.Errorf("failed to stat archive: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to close archive: %w", err)
+	}
+
+	path := filepath.Join(e.cfg.Dir, export.ID+".zip")
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, fmt.Errorf("failed to save archive: %w", err)
+	}
+
+	return path, info.Size(), nil
+}
+
+type archiveFile struct {
+	name string
+	data any
+}
+
+// collect читает данные пользователя, которые попадают в архив
+func (e *Exporter) collect(ctx context.Context, userID int) ([]archiveFile, error) {
+	user, err := e.repo.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := e.repo.GetProfileByUserId(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	photos, err := e.repo.GetUserPhotos(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences, err := e.repo.GetDiscoveryPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	incoming, err := e.repo.GetIncomingResponses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	outgoing, err := e.repo.GetOutgoingResponses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, err := e.repo.GetNotifications(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	account := map[string]any{
+		"id":                user.ID,
+		"username":          user.Username,
+		"email":             user.Email,
+		"email_verified_at": user.EmailVerifiedAt,
+		"totp_enabled":      user.TOTPEnabled,
+		"roles":             user.Roles,
+		"exported_at":       time.Now(),
+	}
+
+	return []archiveFile{
+		{name: "account.json", data: account},
+		{name: "profile.json", data: profile},
+		{name: "photos.json", data: photos},
+		{name: "preferences.json", data: preferences},
+		{name: "responses_incoming.json", data: nonNil(incoming)},
+		{name: "responses_outgoing.json", data: nonNil(outgoing)},
+		{name: "notifications.json", data: nonNil(notifications)},
+	}, nil
+}
+
+// purge удаляет истекшие выгрузки и их архивы
+func (e *Exporter) purge(ctx context.Context, log *slog.Logger) {
+	paths, err := e.repo.DeleteExpiredDataExports(ctx, time.Now())
+	if err != nil {
+		log.Error("failed to purge data exports", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Error("failed to remove data export archive", slog.String("error", err.Error()))
+		}
+	}
+
+	log.Debug("expired data exports purged", slog.Int("count", len(paths)))
+}
+
+// nonNil пустой список вместо null в JSON
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+
+	return items
+}
@@ -0,0 +1,138 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"passion-pals-backend/internal/config"
+	"slices"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrImageTooLarge     = errors.New("image resolution is too large")
+)
+
+// supportedFormats форматы, зарегистрированные импортами выше
+var supportedFormats = []string{"jpeg", "png", "webp"}
+
+// Variant копия изображения в JPEG, уменьшенная до Size пикселей по большей стороне
+type Variant struct {
+	Size   int
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Processor проверяет загруженные изображения и готовит их копии
+type Processor struct {
+	maxPixels int
+	sizes     []int
+	quality   int
+}
+
+func New(cfg config.PhotosConfig) (*Processor, error) {
+	if len(cfg.Sizes) == 0 || slices.Min(cfg.Sizes) <= 0 {
+		return nil, fmt.Errorf("images: photo sizes must be positive, got %v", cfg.Sizes)
+	}
+	if cfg.JPEGQuality < 1 || cfg.JPEGQuality > 100 {
+		return nil, fmt.Errorf("images: jpeg quality must be between 1 and 100, got %d", cfg.JPEGQuality)
+	}
+
+	// От большей копии к меньшей: каждая следующая уменьшается из предыдущей
+	sizes := slices.Clone(cfg.Sizes)
+	slices.Sort(sizes)
+	slices.Reverse(sizes)
+
+	return &Processor{
+		maxPixels: cfg.MaxPixels,
+		sizes:     slices.Compact(sizes),
+		quality:   cfg.JPEGQuality,
+	}, nil
+}
+
+// Process декодирует изображение (JPEG, PNG или WebP), поворачивает его по EXIF Orientation
+// и кодирует заново копии всех размеров. Меньшие изображения не увеличиваются, прозрачность
+// заменяется белым фоном. Метаданные (EXIF, в том числе GPS, и прочие) в копии не попадают.
+func (p *Processor) Process(data []byte) ([]Variant, error) {
+	// Размер проверяется по заголовку до декодирования, чтобы не распаковывать "бомбы"
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || !slices.Contains(supportedFormats, format) {
+		return nil, ErrUnsupportedFormat
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrUnsupportedFormat
+	}
+	if cfg.Width > p.maxPixels/cfg.Height {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	orientation := 1
+	if format == "jpeg" {
+		orientation = exifOrientation(data)
+	}
+
+	variants := make([]Variant, 0, len(p.sizes))
+
+	// Поворачиваем уже уменьшенную копию: ограничение по большей стороне от поворота не зависит
+	var previous image.Image
+	for i, size := range p.sizes {
+		var scaled *image.RGBA
+		if i == 0 {
+			scaled = orient(resize(src, size), orientation)
+		} else {
+			scaled = resize(previous, size)
+		}
+		previous = scaled
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: p.quality}); err != nil {
+			return nil, fmt.Errorf("images: failed to encode jpeg: %w", err)
+		}
+
+		variants = append(variants, Variant{
+			Size:   size,
+			Width:  scaled.Bounds().Dx(),
+			Height: scaled.Bounds().Dy(),
+			Data:   buf.Bytes(),
+		})
+	}
+
+	// Наружу - от меньшей копии к большей
+	slices.Reverse(variants)
+
+	return variants, nil
+}
+
+// resize уменьшает изображение так, чтобы большая сторона не превышала size,
+// и накладывает его на белый фон
+func resize(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, height*size/width)
+		} else {
+			width, height = max(1, width*size/height), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	return dst
+}
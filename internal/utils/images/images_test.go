@@ -0,0 +1,318 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"passion-pals-backend/internal/config"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+func newTestProcessor(t *testing.T, maxPixels int, sizes ...int) *Processor {
+	t.Helper()
+
+	p, err := New(config.PhotosConfig{MaxPixels: maxPixels, Sizes: sizes, JPEGQuality: 90})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+// halves изображение width x height: левая половина красная, правая синяя
+func halves(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.SetRGBA(x, y, red)
+			} else {
+				img.SetRGBA(x, y, blue)
+			}
+		}
+	}
+
+	return img
+}
+
+// exifSegment сегмент APP1 с тегом Orientation и строкой ImageDescription (как метка метаданных)
+func exifSegment(orientation int, order binary.ByteOrder) []byte {
+	const description = "SECRET-GPS-METADATA\x00"
+
+	tiff := make([]byte, 8+2+2*12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 2)
+
+	// ImageDescription (ASCII), значение после IFD
+	entry := tiff[10:]
+	order.PutUint16(entry[0:], 0x010E)
+	order.PutUint16(entry[2:], 2)
+	order.PutUint32(entry[4:], uint32(len(description)))
+	order.PutUint32(entry[8:], uint32(len(tiff)))
+
+	// Orientation (SHORT)
+	entry = tiff[22:]
+	order.PutUint16(entry[0:], exifOrientationTag)
+	order.PutUint16(entry[2:], 3)
+	order.PutUint32(entry[4:], 1)
+	order.PutUint16(entry[8:], uint16(orientation))
+
+	payload := append([]byte("Exif\x00\x00"), append(tiff, description...)...)
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+
+	return append(segment, payload...)
+}
+
+// encodeJPEG кодирует изображение в JPEG и вставляет сразу после SOI сегмент Exif (если orientation > 0)
+func encodeJPEG(t *testing.T, img image.Image, orientation int, order binary.ByteOrder) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	if orientation == 0 {
+		return data
+	}
+
+	return append(append([]byte{0xFF, 0xD8}, exifSegment(orientation, order)...), data[2:]...)
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// near цвета совпадают с точностью до артефактов JPEG
+func near(got color.Color, want color.RGBA) bool {
+	r, g, b, _ := got.RGBA()
+	diff := func(a uint32, b uint8) int {
+		d := int(a>>8) - int(b)
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+
+	return diff(r, want.R) < 40 && diff(g, want.G) < 40 && diff(b, want.B) < 40
+}
+
+func decode(t *testing.T, data []byte) image.Image {
+	t.Helper()
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "jpeg" {
+		t.Fatalf("variant format = %s, want jpeg", format)
+	}
+
+	return img
+}
+
+func TestProcessSizes(t *testing.T) {
+	p := newTestProcessor(t, 1_000_000, 40, 10, 400, 10)
+
+	variants, err := p.Process(encodePNG(t, halves(200, 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Повторяющиеся размеры схлопываются, копии - от меньшей к большей, без увеличения
+	want := []struct{ size, width, height int }{
+		{10, 10, 5},
+		{40, 40, 20},
+		{400, 200, 100},
+	}
+	if len(variants) != len(want) {
+		t.Fatalf("got %d variants, want %d", len(variants), len(want))
+	}
+
+	for i, w := range want {
+		v := variants[i]
+		if v.Size != w.size || v.Width != w.width || v.Height != w.height {
+			t.Errorf("variant %d = %dpx %dx%d, want %dpx %dx%d", i, v.Size, v.Width, v.Height, w.size, w.width, w.height)
+		}
+		if bounds := decode(t, v.Data).Bounds(); bounds.Dx() != v.Width || bounds.Dy() != v.Height {
+			t.Errorf("variant %d encoded as %v, want %dx%d", i, bounds, v.Width, v.Height)
+		}
+	}
+}
+
+func TestProcessOrientation(t *testing.T) {
+	// Исходник 80x40: слева красный, справа синий. corners - ожидаемые цвета
+	// у верхнего левого и нижнего правого угла результата.
+	tests := []struct {
+		orientation   int
+		order         binary.ByteOrder
+		width, height int
+		topLeft       color.RGBA
+		bottomRight   color.RGBA
+	}{
+		{1, binary.LittleEndian, 80, 40, red, blue},
+		{2, binary.LittleEndian, 80, 40, blue, red},
+		{3, binary.BigEndian, 80, 40, blue, red},
+		{4, binary.LittleEndian, 80, 40, red, blue},
+		{5, binary.LittleEndian, 40, 80, red, blue},
+		{6, binary.BigEndian, 40, 80, red, blue},
+		{7, binary.LittleEndian, 40, 80, blue, red},
+		{8, binary.LittleEndian, 40, 80, blue, red},
+	}
+
+	p := newTestProcessor(t, 1_000_000, 200)
+
+	for _, tt := range tests {
+		data := encodeJPEG(t, halves(80, 40), tt.orientation, tt.order)
+
+		if got := exifOrientation(data); got != tt.orientation {
+			t.Errorf("orientation %d: exifOrientation = %d", tt.orientation, got)
+			continue
+		}
+
+		variants, err := p.Process(data)
+		if err != nil {
+			t.Fatalf("orientation %d: %v", tt.orientation, err)
+		}
+
+		v := variants[0]
+		if v.Width != tt.width || v.Height != tt.height {
+			t.Errorf("orientation %d: size %dx%d, want %dx%d", tt.orientation, v.Width, v.Height, tt.width, tt.height)
+			continue
+		}
+
+		img := decode(t, v.Data)
+		if c := img.At(3, 3); !near(c, tt.topLeft) {
+			t.Errorf("orientation %d: top left = %v, want %v", tt.orientation, c, tt.topLeft)
+		}
+		if c := img.At(v.Width-4, v.Height-4); !near(c, tt.bottomRight) {
+			t.Errorf("orientation %d: bottom right = %v, want %v", tt.orientation, c, tt.bottomRight)
+		}
+	}
+}
+
+func TestProcessStripsMetadata(t *testing.T) {
+	p := newTestProcessor(t, 1_000_000, 20, 100)
+
+	data := encodeJPEG(t, halves(60, 30), 1, binary.LittleEndian)
+	if !bytes.Contains(data, []byte("SECRET-GPS-METADATA")) {
+		t.Fatal("test image has no metadata")
+	}
+
+	variants, err := p.Process(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range variants {
+		if bytes.Contains(v.Data, []byte("Exif")) || bytes.Contains(v.Data, []byte("SECRET-GPS-METADATA")) {
+			t.Errorf("%dpx variant keeps metadata", v.Size)
+		}
+		if got := exifOrientation(v.Data); got != 1 {
+			t.Errorf("%dpx variant has orientation %d", v.Size, got)
+		}
+	}
+}
+
+// Прозрачные области заменяются белым фоном
+func TestProcessTransparency(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+
+	variants, err := newTestProcessor(t, 1_000_000, 20).Process(encodePNG(t, img))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c := decode(t, variants[0].Data).At(10, 10); !near(c, color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("transparent pixel = %v, want white", c)
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	var gifData bytes.Buffer
+	if err := gif.Encode(&gifData, image.NewPaletted(image.Rect(0, 0, 10, 10), []color.Color{red}), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	jpegData := encodeJPEG(t, halves(100, 50), 0, nil)
+
+	tests := []struct {
+		name      string
+		maxPixels int
+		data      []byte
+		want      error
+	}{
+		{"empty", 1_000_000, nil, ErrUnsupportedFormat},
+		{"garbage", 1_000_000, []byte("definitely not an image"), ErrUnsupportedFormat},
+		{"gif", 1_000_000, gifData.Bytes(), ErrUnsupportedFormat},
+		{"truncated jpeg", 1_000_000, jpegData[:len(jpegData)/2], ErrUnsupportedFormat},
+		{"too many pixels", 4999, jpegData, ErrImageTooLarge},
+		{"exactly at limit", 5000, jpegData, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestProcessor(t, tt.maxPixels, 50).Process(tt.data)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExifOrientationMalformed(t *testing.T) {
+	valid := encodeJPEG(t, halves(8, 8), 6, binary.LittleEndian)
+
+	// Сегмент Exif начинается сразу после SOI: 2 байта маркера, 2 байта длины, "Exif\0\0", TIFF
+	tiffStart := 2 + 4 + 6
+	// Значение Orientation во второй записи IFD
+	valueOffset := tiffStart + 22 + 8
+
+	tests := []struct {
+		name   string
+		modify func(data []byte) []byte
+	}{
+		{"not jpeg", func(data []byte) []byte { return encodePNG(t, halves(8, 8)) }},
+		{"too short", func(data []byte) []byte { return data[:3] }},
+		{"bad byte order", func(data []byte) []byte { copy(data[tiffStart:], "XX"); return data }},
+		{"value out of range", func(data []byte) []byte { data[valueOffset] = 9; return data }},
+		{"zero value", func(data []byte) []byte { data[valueOffset] = 0; return data }},
+		{"ifd offset past end", func(data []byte) []byte { data[tiffStart+4] = 0xFF; return data }},
+		{"segment length past end", func(data []byte) []byte { data[4], data[5] = 0xFF, 0xFF; return data[:100] }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.modify(bytes.Clone(valid))
+			if got := exifOrientation(data); got != 1 {
+				t.Errorf("exifOrientation = %d, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package keyring
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"passion-pals-backend/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown signing key")
+	ErrNoKID      = errors.New("token has no kid header")
+)
+
+// key ключ подписи. Если signKey == nil, ключ используется только для проверки
+// (например, выведенный из ротации ключ, которым ещё подписаны живые токены).
+type key struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// Keyring набор ключей для подписи и проверки JWT.
+// Подписывает всегда активный ключ, проверяет любой ключ из набора по заголовку kid.
+type Keyring struct {
+	issuer   string
+	audience string
+	signing  *key
+	keys     map[string]*key
+	methods  []string
+}
+
+// New загружает ключи из конфигурации
+func New(cfg config.JWTConfig) (*Keyring, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, errors.New("keyring: no keys configured")
+	}
+
+	k := &Keyring{
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		keys:     make(map[string]*key, len(cfg.Keys)),
+	}
+
+	seenMethods := make(map[string]bool)
+
+	for _, keyCfg := range cfg.Keys {
+		if keyCfg.KID == "" {
+			return nil, errors.New("keyring: key without kid")
+		}
+		if _, ok := k.keys[keyCfg.KID]; ok {
+			return nil, fmt.Errorf("keyring: duplicate kid %q", keyCfg.KID)
+		}
+
+		parsed, err := loadKey(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: key %q: %w", keyCfg.KID, err)
+		}
+
+		k.keys[parsed.kid] = parsed
+
+		if !seenMethods[parsed.method.Alg()] {
+			seenMethods[parsed.method.Alg()] = true
+			k.methods = append(k.methods, parsed.method.Alg())
+		}
+	}
+
+	signing, ok := k.keys[cfg.SigningKey]
+	if !ok {
+		return nil, fmt.Errorf("keyring: signing key %q is not configured", cfg.SigningKey)
+	}
+	if signing.signKey == nil {
+		return nil, fmt.Errorf("keyring: signing key %q has no private key", cfg.SigningKey)
+	}
+	k.signing = signing
+
+	return k, nil
+}
+
+// Issuer значение iss для выпускаемых токенов
+func (k *Keyring) Issuer() string {
+	return k.issuer
+}
+
+// Audience значение aud для access-токенов
+func (k *Keyring) Audience() string {
+	return k.audience
+}
+
+// Sign подписывает claims активным ключом и проставляет его kid в заголовок
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signing.method, claims)
+	token.Header["kid"] = k.signing.kid
+
+	return token.SignedString(k.signing.signKey)
+}
+
+// Parse проверяет токен, выпущенный для аудитории access-токенов
+func (k *Keyring) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return k.ParseFor(tokenString, claims, k.audience)
+}
+
+// ParseFor проверяет подпись, срок действия, издателя и аудиторию токена
+func (k *Keyring) ParseFor(tokenString string, claims jwt.Claims, audience string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, k.keyFunc,
+		jwt.WithValidMethods(k.methods),
+		jwt.WithIssuer(k.issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+	)
+}
+
+func (k *Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, ErrNoKID
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	// Алгоритм берём из конфигурации ключа, а не из заголовка токена
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.verifyKey, nil
+}
+
+func loadKey(cfg config.JWTKey) (*key, error) {
+	switch cfg.Algorithm {
+	case "HS256":
+		secret := string(cfg.Secret)
+		if cfg.SecretEnv != "" {
+			secret = os.Getenv(cfg.SecretEnv)
+		}
+		if len(secret) < 32 {
+			return nil, errors.New("HS256 secret must be at least 32 bytes")
+		}
+
+		return &key{
+			kid:       cfg.KID,
+			method:    jwt.SigningMethodHS256,
+			signKey:   []byte(secret),
+			verifyKey: []byte(secret),
+		}, nil
+
+	case "RS256":
+		k := &key{kid: cfg.KID, method: jwt.SigningMethodRS256}
+
+		if cfg.PrivateKeyFile != "" {
+			data, err := os.ReadFile(cfg.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.signKey = private
+			k.verifyKey = &private.PublicKey
+		}
+
+		if cfg.PublicKeyFile != "" {
+			data, err := os.ReadFile(cfg.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.verifyKey = public
+		}
+
+		if k.verifyKey == nil {
+			return nil, errors.New("RS256 key requires private_key_file or public_key_file")
+		}
+
+		return k, nil
+
+	case "EdDSA":
+		k := &key{kid: cfg.KID, method: jwt.SigningMethodEdDSA}
+
+		if cfg.PrivateKeyFile != "" {
+			data, err := os.ReadFile(cfg.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			signer, ok := private.(interface{ Public() crypto.PublicKey })
+			if !ok {
+				return nil, errors.New("invalid Ed25519 private key")
+			}
+			k.signKey = private
+			k.verifyKey = signer.Public()
+		}
+
+		if cfg.PublicKeyFile != "" {
+			data, err := os.ReadFile(cfg.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseEdPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.verifyKey = public
+		}
+
+		if k.verifyKey == nil {
+			return nil, errors.New("EdDSA key requires private_key_file or public_key_file")
+		}
+
+		return k, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+}
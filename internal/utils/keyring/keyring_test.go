@@ -0,0 +1,259 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"passion-pals-backend/internal/config"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// testKeys пишет ключи RS256 и EdDSA в PEM-файлы и возвращает их пути
+type testKeys struct {
+	rsa          *rsa.PrivateKey
+	rsaPrivate   string
+	rsaPublic    string
+	rsaPublicPEM []byte
+	ed25519Key   string
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	dir := t.TempDir()
+	write := func(name, blockType string, der []byte) (string, []byte) {
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path, data
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPublicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPrivateDER, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := &testKeys{rsa: rsaKey}
+	keys.rsaPrivate, _ = write("rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	keys.rsaPublic, keys.rsaPublicPEM = write("rsa.pub.pem", "PUBLIC KEY", rsaPublicDER)
+	keys.ed25519Key, _ = write("ed25519.pem", "PRIVATE KEY", edPrivateDER)
+
+	return keys
+}
+
+func newKeyring(t *testing.T, signingKey string, keys ...config.JWTKey) *Keyring {
+	t.Helper()
+
+	k, err := New(config.JWTConfig{Issuer: "passion-pals", Audience: "passion-pals-api", SigningKey: signingKey, Keys: keys})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return k
+}
+
+func accessClaims(k *Keyring, now time.Time) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "1",
+		"iss": k.Issuer(),
+		"aud": k.Audience(),
+		"iat": now.Unix(),
+		"exp": now.Add(time.Minute).Unix(),
+	}
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	keys := newTestKeys(t)
+
+	tests := []struct {
+		name       string
+		signingKey string
+		keys       []config.JWTKey
+	}{
+		{"no keys", "a", nil},
+		{"key without kid", "", []config.JWTKey{{Algorithm: "HS256", Secret: testSecret}}},
+		{"duplicate kid", "a", []config.JWTKey{
+			{KID: "a", Algorithm: "HS256", Secret: testSecret},
+			{KID: "a", Algorithm: "HS256", Secret: testSecret},
+		}},
+		{"short secret", "a", []config.JWTKey{{KID: "a", Algorithm: "HS256", Secret: "short"}}},
+		{"unsupported algorithm", "a", []config.JWTKey{{KID: "a", Algorithm: "none"}}},
+		{"RS256 without files", "a", []config.JWTKey{{KID: "a", Algorithm: "RS256"}}},
+		{"unknown signing key", "b", []config.JWTKey{{KID: "a", Algorithm: "HS256", Secret: testSecret}}},
+		{"verify-only signing key", "a", []config.JWTKey{{KID: "a", Algorithm: "RS256", PublicKeyFile: keys.rsaPublic}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(config.JWTConfig{SigningKey: tt.signingKey, Keys: tt.keys}); err == nil {
+				t.Error("New() accepted an invalid config")
+			}
+		})
+	}
+}
+
+func TestSignAndParse(t *testing.T) {
+	keys := newTestKeys(t)
+
+	tests := []struct {
+		name string
+		key  config.JWTKey
+	}{
+		{"HS256", config.JWTKey{KID: "hs", Algorithm: "HS256", Secret: testSecret}},
+		{"RS256", config.JWTKey{KID: "rs", Algorithm: "RS256", PrivateKeyFile: keys.rsaPrivate}},
+		{"EdDSA", config.JWTKey{KID: "ed", Algorithm: "EdDSA", PrivateKeyFile: keys.ed25519Key}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newKeyring(t, tt.key.KID, tt.key)
+
+			signed, err := k.Sign(accessClaims(k, time.Now()))
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+
+			token, err := k.Parse(signed, jwt.MapClaims{})
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if token.Header["kid"] != tt.key.KID || token.Method.Alg() != tt.key.Algorithm {
+				t.Errorf("header = %v", token.Header)
+			}
+		})
+	}
+}
+
+func TestParseSelectsKeyByKid(t *testing.T) {
+	previous := config.JWTKey{KID: "2025", Algorithm: "HS256", Secret: testSecret}
+	current := config.JWTKey{KID: "2026", Algorithm: "HS256", Secret: testSecret + "-2026"}
+
+	// Токен, подписанный выведенным из ротации ключом, проверяется, пока ключ остается в наборе
+	old := newKeyring(t, previous.KID, previous)
+	signed, err := old.Sign(accessClaims(old, time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := newKeyring(t, current.KID, current, previous)
+	if _, err := rotated.Parse(signed, jwt.MapClaims{}); err != nil {
+		t.Errorf("Parse() of a token signed by the previous key error = %v", err)
+	}
+
+	fresh, err := rotated.Sign(accessClaims(rotated, time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Parse(fresh, jwt.MapClaims{}); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Parse() with an unknown kid error = %v, want %v", err, ErrUnknownKey)
+	}
+}
+
+func TestParseRejectsInvalidTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	k := newKeyring(t, "rs",
+		config.JWTKey{KID: "rs", Algorithm: "RS256", PrivateKeyFile: keys.rsaPrivate},
+		config.JWTKey{KID: "hs", Algorithm: "HS256", Secret: testSecret},
+	)
+	now := time.Now()
+
+	sign := func(method jwt.SigningMethod, kid string, claims jwt.MapClaims, signKey interface{}) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(signKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	with := func(key string, value interface{}) jwt.MapClaims {
+		claims := accessClaims(k, now)
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"no kid", sign(jwt.SigningMethodRS256, "", accessClaims(k, now), keys.rsa)},
+		{"unknown kid", sign(jwt.SigningMethodRS256, "other", accessClaims(k, now), keys.rsa)},
+		// Подмена алгоритма: публичный ключ RS256 в роли секрета HMAC под kid RSA-ключа
+		{"alg confusion", sign(jwt.SigningMethodHS256, "rs", accessClaims(k, now), keys.rsaPublicPEM)},
+		{"alg of another key", sign(jwt.SigningMethodHS256, "rs", accessClaims(k, now), []byte(testSecret))},
+		{"alg none", sign(jwt.SigningMethodNone, "rs", accessClaims(k, now), jwt.UnsafeAllowNoneSignatureType)},
+		{"wrong issuer", sign(jwt.SigningMethodRS256, "rs", with("iss", "other"), keys.rsa)},
+		{"wrong audience", sign(jwt.SigningMethodRS256, "rs", with("aud", "other"), keys.rsa)},
+		{"expired", sign(jwt.SigningMethodRS256, "rs", with("exp", now.Add(-time.Minute).Unix()), keys.rsa)},
+		{"no expiration", sign(jwt.SigningMethodRS256, "rs", with("exp", nil), keys.rsa)},
+		{"tampered payload", tamper(sign(jwt.SigningMethodRS256, "rs", accessClaims(k, now), keys.rsa))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := k.Parse(tt.token, jwt.MapClaims{}); err == nil {
+				t.Error("Parse() accepted an invalid token")
+			}
+		})
+	}
+}
+
+func TestParseForChecksAudience(t *testing.T) {
+	k := newKeyring(t, "hs", config.JWTKey{KID: "hs", Algorithm: "HS256", Secret: testSecret})
+
+	claims := accessClaims(k, time.Now())
+	claims["aud"] = "mfa"
+	signed, err := k.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := k.ParseFor(signed, jwt.MapClaims{}, "mfa"); err != nil {
+		t.Errorf("ParseFor() error = %v", err)
+	}
+	// Промежуточный токен нельзя предъявить как access-токен
+	if _, err := k.Parse(signed, jwt.MapClaims{}); err == nil {
+		t.Error("Parse() accepted a token issued for another audience")
+	}
+}
+
+// tamper подменяет sub в payload токена, сохраняя подпись
+func tamper(token string) string {
+	parts := strings.Split(token, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(payload), `"sub":"1"`, `"sub":"2"`, 1)))
+	return strings.Join(parts, ".")
+}
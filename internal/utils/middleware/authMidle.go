@@ -1,17 +1,40 @@
 package middleware
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"net/http"
+	"passion-pals-backend/internal/utils/keyring"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware проверяет JWT токен
-func AuthMiddleware() gin.HandlerFunc {
+// RevocationChecker список отозванных токенов
+type RevocationChecker interface {
+	IsRevoked(jti string, userID int, issuedAt time.Time) bool
+}
+
+// SessionChecker отмечает активность сессии и сообщает, не отозвана ли она
+type SessionChecker interface {
+	Touch(ctx context.Context, sessionID string) (bool, error)
+}
+
+// APIKeyAuthenticator проверяет API-ключ. ok == false - ключ недействителен.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key, ip string) (*Claims, bool, error)
+}
+
+// apiKeyScheme схема заголовка Authorization для API-ключей
+const apiKeyScheme = "ApiKey "
+
+// AuthMiddleware проверяет JWT токен (Authorization: Bearer ...) по набору ключей keys,
+// списку отозванных токенов и сессиям, а также API-ключи (Authorization: ApiKey ...).
+// Если apiKeys == nil, API-ключи не принимаются: так защищаются маршруты управления
+// учетной записью, доступные только в интерактивной сессии.
+func AuthMiddleware(keys *keyring.Keyring, revoked RevocationChecker, sessions SessionChecker, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -19,6 +42,27 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if strings.HasPrefix(authHeader, apiKeyScheme) {
+			if apiKeys == nil {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API keys are not allowed for this endpoint"})
+				return
+			}
+
+			claims, ok, err := apiKeys.Authenticate(c.Request.Context(), strings.TrimPrefix(authHeader, apiKeyScheme), c.ClientIP())
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check API key"})
+				return
+			}
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				return
+			}
+
+			c.Set(claimsKey, claims)
+			c.Next()
+			return
+		}
+
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
 		if tokenString == authHeader {
@@ -26,13 +70,9 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Парсим токен
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte("your_secret_key"), nil // Замените на ваш секретный ключ
-		})
+		// Парсим токен: подпись, kid, exp, iss и aud проверяются в keyring
+		claims := &Claims{}
+		token, err := keys.Parse(tokenString, claims)
 
 		if err != nil {
 			if errors.Is(err, jwt.ErrTokenExpired) {
@@ -47,15 +87,30 @@ func AuthMiddleware() gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			return
 		}
-		// Если токен валиден, извлекаем claims и сохраняем их в контексте
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Set("userClaims", claims)
-			c.Next()
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+
+		if claims.ID == "" || claims.SessionID == "" || claims.UserID == 0 || claims.IssuedAt == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+
+		if revoked.IsRevoked(claims.ID, claims.UserID, claims.IssuedAt.Time) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			return
+		}
+
+		// Отмечаем активность устройства и отклоняем токены отозванных сессий
+		active, err := sessions.Touch(c.Request.Context(), claims.SessionID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check session"})
+			return
+		}
+		if !active {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
 			return
 		}
+
+		// Если токен валиден, сохраняем claims в контексте
+		c.Set(claimsKey, claims)
 		c.Next()
 	}
 }
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// claimsKey ключ, под которым AuthMiddleware кладет claims в контекст gin
+const claimsKey = "userClaims"
+
+// Claims данные access-токена пользователя
+type Claims struct {
+	UserID    int      `json:"user_id"`
+	Roles     []string `json:"roles,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	// APIKeyID ключ, которым аутентифицирован запрос (0 для JWT). В токен не попадает.
+	APIKeyID int64 `json:"-"`
+	jwt.RegisteredClaims
+}
+
+// HasRole сообщает, есть ли у пользователя роль role
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// HasScope сообщает, разрешена ли токену область доступа scope
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// ClaimsFromContext возвращает claims, сохраненные AuthMiddleware
+func ClaimsFromContext(c *gin.Context) (*Claims, bool) {
+	value, exists := c.Get(claimsKey)
+	if !exists {
+		return nil, false
+	}
+
+	claims, ok := value.(*Claims)
+	return claims, ok
+}
+
+// RequireRole пропускает только пользователей, у которых есть хотя бы одна из ролей.
+// Используется после AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
+			return
+		}
+
+		for _, role := range roles {
+			if claims.HasRole(role) {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient role"})
+	}
+}
+
+// RequireScope пропускает только токены, которым разрешены все области доступа scopes.
+// Используется после AuthMiddleware.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
+			return
+		}
+
+		for _, scope := range scopes {
+			if !claims.HasScope(scope) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient scope", "scope": scope})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
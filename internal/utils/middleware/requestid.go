@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader заголовок с идентификатором запроса
+	RequestIDHeader = "X-Request-ID"
+
+	requestIDKey       = "requestID"
+	maxRequestIDLength = 64
+)
+
+// RequestID присваивает запросу идентификатор для сопоставления логов и журнала безопасности.
+// Идентификатор из заголовка X-Request-ID (например, от балансировщика) сохраняется,
+// если он корректен, иначе генерируется новый. Идентификатор возвращается в ответе.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Set(requestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// RequestIDFromContext возвращает идентификатор текущего запроса
+func RequestIDFromContext(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// validRequestID допускает только короткие идентификаторы из безопасных символов,
+// чтобы клиент не мог записать в журнал произвольный текст
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range requestID {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' && r != '.' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,111 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var errKeyNotFound = errors.New("oidc: signing key not found in jwks")
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// jwks публичные ключи провайдера по kid
+type jwks struct {
+	keys map[string]interface{}
+}
+
+func (k *jwks) lookup(kid string) (interface{}, error) {
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+
+	// Провайдеры с единственным ключом иногда не указывают kid в токене
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, nil
+		}
+	}
+
+	return nil, errKeyNotFound
+}
+
+func parseJWKS(set jsonWebKeySet) (*jwks, error) {
+	keys := make(map[string]interface{}, len(set.Keys))
+
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		switch key.Kty {
+		case "RSA":
+			n, err := decodeBigInt(key.N)
+			if err != nil {
+				return nil, fmt.Errorf("oidc: invalid RSA key %q: %w", key.Kid, err)
+			}
+			e, err := decodeBigInt(key.E)
+			if err != nil {
+				return nil, fmt.Errorf("oidc: invalid RSA key %q: %w", key.Kid, err)
+			}
+
+			keys[key.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+
+		case "EC":
+			var curve elliptic.Curve
+			switch key.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+
+			x, err := decodeBigInt(key.X)
+			if err != nil {
+				return nil, fmt.Errorf("oidc: invalid EC key %q: %w", key.Kid, err)
+			}
+			y, err := decodeBigInt(key.Y)
+			if err != nil {
+				return nil, fmt.Errorf("oidc: invalid EC key %q: %w", key.Kid, err)
+			}
+
+			keys[key.Kid] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("oidc: jwks has no usable signing keys")
+	}
+
+	return &jwks{keys: keys}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,304 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"passion-pals-backend/internal/config"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrNonceMismatch = errors.New("oidc: nonce mismatch")
+
+// Discovery нужные нам поля документа /.well-known/openid-configuration
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// IDTokenClaims claims ID-токена, которые используются для входа
+type IDTokenClaims struct {
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+// Provider OpenID Connect провайдер (relying party, authorization code + PKCE).
+// Документ discovery и ключи JWKS загружаются при первом обращении и кэшируются.
+type Provider struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	client       *http.Client
+
+	mu        sync.Mutex
+	discovery *Discovery
+	jwks      *jwks
+}
+
+// NewProviders создает провайдеров из конфигурации, ключ карты - имя провайдера
+func NewProviders(cfg config.OIDCConfig) (map[string]*Provider, error) {
+	providers := make(map[string]*Provider, len(cfg.Providers))
+
+	for _, providerCfg := range cfg.Providers {
+		if providerCfg.Name == "" || providerCfg.Issuer == "" || providerCfg.ClientID == "" {
+			return nil, errors.New("oidc: provider requires name, issuer and client_id")
+		}
+		if _, ok := providers[providerCfg.Name]; ok {
+			return nil, fmt.Errorf("oidc: duplicate provider %q", providerCfg.Name)
+		}
+
+		scopes := providerCfg.Scopes
+		if len(scopes) == 0 {
+			scopes = []string{"openid", "email", "profile"}
+		}
+
+		providers[providerCfg.Name] = &Provider{
+			name:         providerCfg.Name,
+			issuer:       strings.TrimSuffix(providerCfg.Issuer, "/"),
+			clientID:     providerCfg.ClientID,
+			clientSecret: string(providerCfg.ClientSecret),
+			redirectURL:  strings.TrimSuffix(cfg.RedirectBaseURL, "/") + "/oauth/" + providerCfg.Name + "/callback",
+			scopes:       scopes,
+			client:       &http.Client{Timeout: 10 * time.Second},
+		}
+	}
+
+	return providers, nil
+}
+
+// Name имя провайдера из конфигурации
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL возвращает адрес страницы входа провайдера
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.clientID)
+	query.Set("redirect_uri", p.redirectURL)
+	query.Set("scope", strings.Join(p.scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange обменивает код авторизации на ID-токен и проверяет его
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*IDTokenClaims, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectURL)
+	form.Set("client_id", p.clientID)
+	form.Set("code_verifier", codeVerifier)
+	if p.clientSecret != "" {
+		form.Set("client_secret", p.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokenResponse struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return nil, fmt.Errorf("oidc: invalid token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: token endpoint returned %d: %s %s",
+			resp.StatusCode, tokenResponse.Error, tokenResponse.ErrorDescription)
+	}
+
+	if tokenResponse.IDToken == "" {
+		return nil, errors.New("oidc: token response has no id_token")
+	}
+
+	return p.verifyIDToken(ctx, discovery, tokenResponse.IDToken, nonce)
+}
+
+// verifyIDToken проверяет подпись по JWKS провайдера, iss, aud, exp и nonce
+func (p *Provider) verifyIDToken(ctx context.Context, discovery *Discovery, rawIDToken, nonce string) (*IDTokenClaims, error) {
+	keys, err := p.keys(ctx, discovery)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &IDTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		key, err := keys.lookup(kid)
+		if errors.Is(err, errKeyNotFound) {
+			// Провайдер мог ротировать ключи: перечитываем JWKS один раз
+			keys, err = p.refreshKeys(ctx, discovery)
+			if err != nil {
+				return nil, err
+			}
+			key, err = keys.lookup(kid)
+		}
+
+		return key, err
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid id_token: %w", err)
+	}
+
+	if claims.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("oidc: id_token has no subject")
+	}
+
+	return claims, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var discovery Discovery
+	if err := p.getJSON(ctx, p.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("oidc: discovery failed: %w", err)
+	}
+
+	// OpenID Connect Discovery 1.0, раздел 4.3: issuer должен совпадать с запрошенным
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("oidc: issuer mismatch: expected %q, got %q", p.issuer, discovery.Issuer)
+	}
+
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("oidc: incomplete discovery document")
+	}
+
+	p.discovery = &discovery
+
+	return p.discovery, nil
+}
+
+func (p *Provider) keys(ctx context.Context, discovery *Discovery) (*jwks, error) {
+	p.mu.Lock()
+	keys := p.jwks
+	p.mu.Unlock()
+
+	if keys != nil {
+		return keys, nil
+	}
+
+	return p.refreshKeys(ctx, discovery)
+}
+
+func (p *Provider) refreshKeys(ctx context.Context, discovery *Discovery) (*jwks, error) {
+	var set jsonWebKeySet
+	if err := p.getJSON(ctx, discovery.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc: failed to fetch jwks: %w", err)
+	}
+
+	keys, err := parseJWKS(set)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.jwks = keys
+	p.mu.Unlock()
+
+	return keys, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// RandomString возвращает случайную строку для state, nonce и code_verifier
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge вычисляет PKCE code_challenge методом S256 (RFC 7636)
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
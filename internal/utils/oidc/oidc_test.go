@@ -0,0 +1,360 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"passion-pals-backend/internal/config"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID = "passion-pals"
+	testKid      = "test-key"
+)
+
+// mockIdP локальный провайдер OpenID Connect: discovery, страница входа,
+// token endpoint с проверкой PKCE и JWKS
+type mockIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	// issuer, который провайдер указывает в discovery; по умолчанию - адрес сервера
+	issuer string
+	// tamper изменяет claims и заголовок ID-токена перед подписью
+	tamper func(claims jwt.MapClaims, token *jwt.Token)
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+type mockAuthorization struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &mockIdP{t: t, key: key, codes: make(map[string]mockAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", idp.jwks)
+
+	idp.server = httptest.NewServer(mux)
+	idp.issuer = idp.server.URL
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (idp *mockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 idp.issuer,
+		"authorization_endpoint": idp.server.URL + "/authorize",
+		"token_endpoint":         idp.server.URL + "/token",
+		"jwks_uri":               idp.server.URL + "/jwks",
+	})
+}
+
+// authorize сразу "входит" и перенаправляет обратно с кодом и тем же state
+func (idp *mockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != testClientID ||
+		query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" ||
+		query.Get("state") == "" || query.Get("nonce") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	// Обработчик работает в горутине сервера: t.Fatal здесь недопустим
+	code, err := RandomString()
+	if err != nil {
+		idp.t.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	idp.mu.Lock()
+	idp.codes[code] = mockAuthorization{
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+		redirectURI: query.Get("redirect_uri"),
+	}
+	idp.mu.Unlock()
+
+	redirect := url.Values{"code": {code}, "state": {query.Get("state")}}
+	http.Redirect(w, r, query.Get("redirect_uri")+"?"+redirect.Encode(), http.StatusFound)
+}
+
+// token обменивает код на ID-токен; код одноразовый, code_verifier должен
+// соответствовать code_challenge из запроса авторизации (RFC 7636)
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	idp.mu.Lock()
+	authorization, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("client_id") != testClientID ||
+		r.PostForm.Get("redirect_uri") != authorization.redirectURI {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	if CodeChallenge(r.PostForm.Get("code_verifier")) != authorization.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            idp.issuer,
+		"aud":            testClientID,
+		"sub":            "subject-1",
+		"nonce":          authorization.nonce,
+		"email":          "user@example.com",
+		"email_verified": true,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Minute).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKid
+	if idp.tamper != nil {
+		idp.tamper(claims, token)
+	}
+
+	var key interface{} = idp.key
+	switch token.Method {
+	case jwt.SigningMethodNone:
+		key = jwt.UnsafeAllowNoneSignatureType
+	case jwt.SigningMethodHS256:
+		// Подмена алгоритма: публичный ключ из JWKS в роли секрета HMAC
+		key = idp.key.N.Bytes()
+	}
+
+	idToken, err := token.SignedString(key)
+	if err != nil {
+		idp.t.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"id_token": idToken, "token_type": "Bearer"})
+}
+
+func (idp *mockIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, jsonWebKeySet{Keys: []jsonWebKey{{
+		Kty: "RSA",
+		Kid: testKid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+	}}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (idp *mockIdP) provider(t *testing.T) *Provider {
+	t.Helper()
+
+	providers, err := NewProviders(config.OIDCConfig{
+		RedirectBaseURL: "http://app.test",
+		Providers: []config.OIDCProviderConfig{{
+			Name:     "mock",
+			Issuer:   idp.server.URL,
+			ClientID: testClientID,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return providers["mock"]
+}
+
+// login проходит страницу входа провайдера и возвращает code и state из перенаправления
+func (idp *mockIdP) login(t *testing.T, provider *Provider, state, nonce, codeVerifier string) (string, string) {
+	t.Helper()
+
+	authURL, err := provider.AuthCodeURL(context.Background(), state, nonce, codeVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned %d", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := location.Scheme + "://" + location.Host + location.Path; got != "http://app.test/oauth/mock/callback" {
+		t.Fatalf("redirected to %q", got)
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestExchange(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider(t)
+
+	code, state := idp.login(t, provider, "state-1", "nonce-1", "verifier-1")
+	if state != "state-1" {
+		t.Fatalf("state = %q, want %q", state, "state-1")
+	}
+
+	claims, err := provider.Exchange(context.Background(), code, "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	if claims.Subject != "subject-1" || claims.Email != "user@example.com" || !claims.EmailVerified {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	// Код одноразовый
+	if _, err := provider.Exchange(context.Background(), code, "verifier-1", "nonce-1"); err == nil {
+		t.Error("Exchange() accepted a used code")
+	}
+}
+
+func TestExchangeRejectsWrongCodeVerifier(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider(t)
+
+	code, _ := idp.login(t, provider, "state", "nonce", "verifier")
+
+	if _, err := provider.Exchange(context.Background(), code, "other-verifier", "nonce"); err == nil {
+		t.Fatal("Exchange() accepted a wrong code_verifier")
+	}
+}
+
+func TestExchangeRejectsNonceMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider(t)
+
+	code, _ := idp.login(t, provider, "state", "nonce", "verifier")
+
+	_, err := provider.Exchange(context.Background(), code, "verifier", "other-nonce")
+	if !errors.Is(err, ErrNonceMismatch) {
+		t.Fatalf("Exchange() error = %v, want %v", err, ErrNonceMismatch)
+	}
+}
+
+func TestExchangeRejectsInvalidIDToken(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(claims jwt.MapClaims, token *jwt.Token)
+	}{
+		{"wrong issuer", func(claims jwt.MapClaims, _ *jwt.Token) { claims["iss"] = "https://evil.example" }},
+		{"wrong audience", func(claims jwt.MapClaims, _ *jwt.Token) { claims["aud"] = "other-client" }},
+		{"expired", func(claims jwt.MapClaims, _ *jwt.Token) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{"no expiration", func(claims jwt.MapClaims, _ *jwt.Token) { delete(claims, "exp") }},
+		{"no subject", func(claims jwt.MapClaims, _ *jwt.Token) { delete(claims, "sub") }},
+		{"unknown kid", func(_ jwt.MapClaims, token *jwt.Token) { token.Header["kid"] = "other-key" }},
+		{"alg none", func(_ jwt.MapClaims, token *jwt.Token) {
+			token.Method = jwt.SigningMethodNone
+			token.Header["alg"] = "none"
+		}},
+		{"alg HS256", func(_ jwt.MapClaims, token *jwt.Token) {
+			token.Method = jwt.SigningMethodHS256
+			token.Header["alg"] = "HS256"
+		}},
+		{"foreign key", func(_ jwt.MapClaims, token *jwt.Token) {
+			token.Method = &foreignKeyMethod{key: otherKey}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			provider := idp.provider(t)
+			idp.tamper = tt.tamper
+
+			code, _ := idp.login(t, provider, "state", "nonce", "verifier")
+
+			if _, err := provider.Exchange(context.Background(), code, "verifier", "nonce"); err == nil {
+				t.Fatal("Exchange() accepted an invalid id_token")
+			}
+		})
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.issuer = "https://evil.example"
+
+	if _, err := idp.provider(t).AuthCodeURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Fatal("AuthCodeURL() accepted a discovery document with a foreign issuer")
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// RFC 7636, приложение B
+	got := CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("CodeChallenge() = %q, want %q", got, want)
+	}
+}
+
+// foreignKeyMethod подписывает RS256 ключом, которого нет в JWKS провайдера
+type foreignKeyMethod struct {
+	key *rsa.PrivateKey
+}
+
+func (m *foreignKeyMethod) Alg() string { return "RS256" }
+
+func (m *foreignKeyMethod) Verify(string, []byte, interface{}) error { return nil }
+
+func (m *foreignKeyMethod) Sign(signingString string, _ interface{}) ([]byte, error) {
+	return jwt.SigningMethodRS256.Sign(signingString, m.key)
+}
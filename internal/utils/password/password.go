@@ -0,0 +1,206 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"passion-pals-backend/internal/config"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var ErrUnknownHashFormat = errors.New("password: unknown hash format")
+
+// Hasher хэширует пароли выбранным в конфиге алгоритмом и проверяет хэши
+// любого поддерживаемого формата: bcrypt ($2a$/$2b$/$2y$) и Argon2id (PHC-строка)
+type Hasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2     config.Argon2Config
+	// dummyHash хэш для выравнивания времени ответа, когда пользователь не найден
+	dummyHash string
+}
+
+func New(cfg config.PasswordConfig) (*Hasher, error) {
+	h := &Hasher{
+		algorithm:  strings.ToLower(cfg.Algorithm),
+		bcryptCost: cfg.BcryptCost,
+		argon2:     cfg.Argon2,
+	}
+
+	switch h.algorithm {
+	case AlgorithmBcrypt:
+		if h.bcryptCost < bcrypt.MinCost || h.bcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("password: bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case AlgorithmArgon2id:
+		if h.argon2.Memory == 0 || h.argon2.Iterations == 0 || h.argon2.Parallelism == 0 ||
+			h.argon2.SaltLength == 0 || h.argon2.KeyLength == 0 {
+			return nil, errors.New("password: argon2id parameters must be positive")
+		}
+	default:
+		return nil, fmt.Errorf("password: unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	dummyHash, err := h.Hash("dummy-password")
+	if err != nil {
+		return nil, err
+	}
+	h.dummyHash = dummyHash
+
+	return h, nil
+}
+
+// Hash возвращает хэш пароля в формате текущего алгоритма
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.algorithm == AlgorithmArgon2id {
+		return h.hashArgon2id(password)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+// Verify проверяет пароль по хэшу. Пустой хэш (учетная запись без пароля) не совпадает ни с чем.
+func (h *Hasher) Verify(password, encoded string) (bool, error) {
+	switch {
+	case encoded == "":
+		// Тратим столько же времени, сколько на настоящую проверку
+		h.VerifyDummy(password)
+		return false, nil
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return verifyArgon2id(password, encoded)
+	case isBcrypt(encoded):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to compare password: %w", err)
+		}
+		return true, nil
+	default:
+		return false, ErrUnknownHashFormat
+	}
+}
+
+// VerifyDummy выполняет проверку по фиктивному хэшу, чтобы ответ для
+// несуществующего пользователя не отличался по времени
+func (h *Hasher) VerifyDummy(password string) {
+	_, _ = h.Verify(password, h.dummyHash)
+}
+
+// NeedsRehash сообщает, что хэш сделан другим алгоритмом или с устаревшими параметрами
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	switch {
+	case encoded == "":
+		return false
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		if h.algorithm != AlgorithmArgon2id {
+			return true
+		}
+		params, _, _, err := decodeArgon2id(encoded)
+		return err != nil || params != h.argon2params()
+	case isBcrypt(encoded):
+		if h.algorithm != AlgorithmBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err != nil || cost != h.bcryptCost
+	default:
+		return true
+	}
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// argon2Params параметры, которые кодируются в PHC-строке
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	keyLength   uint32
+}
+
+func (h *Hasher) argon2params() argon2Params {
+	return argon2Params{
+		memory:      h.argon2.Memory,
+		iterations:  h.argon2.Iterations,
+		parallelism: h.argon2.Parallelism,
+		keyLength:   h.argon2.KeyLength,
+	}
+}
+
+// hashArgon2id возвращает PHC-строку вида $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func (h *Hasher) hashArgon2id(password string) (string, error) {
+	salt := make([]byte, h.argon2.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	p := h.argon2params()
+	key := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func verifyArgon2id(password, encoded string) (bool, error) {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func decodeArgon2id(encoded string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+	p.keyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,170 @@
+package password
+
+import (
+	"errors"
+	"passion-pals-backend/internal/config"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Минимальные параметры, чтобы тесты не тратили время на хэширование
+var (
+	testArgon2 = config.Argon2Config{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	testBcrypt = config.PasswordConfig{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost}
+	testArgon  = config.PasswordConfig{Algorithm: AlgorithmArgon2id, Argon2: testArgon2}
+)
+
+func newHasher(t *testing.T, cfg config.PasswordConfig) *Hasher {
+	t.Helper()
+
+	h, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return h
+}
+
+func hash(t *testing.T, h *Hasher, password string) string {
+	t.Helper()
+
+	encoded, err := h.Hash(password)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	return encoded
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.PasswordConfig
+	}{
+		{"unsupported algorithm", config.PasswordConfig{Algorithm: "md5"}},
+		{"bcrypt cost too low", config.PasswordConfig{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost - 1}},
+		{"bcrypt cost too high", config.PasswordConfig{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MaxCost + 1}},
+		{"argon2id without parameters", config.PasswordConfig{Algorithm: AlgorithmArgon2id}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Error("New() accepted an invalid config")
+			}
+		})
+	}
+}
+
+func TestHashAndVerify(t *testing.T) {
+	for _, cfg := range []config.PasswordConfig{testBcrypt, testArgon, {Algorithm: "ARGON2ID", Argon2: testArgon2}} {
+		t.Run(cfg.Algorithm, func(t *testing.T) {
+			h := newHasher(t, cfg)
+			encoded := hash(t, h, "correct horse")
+
+			if other := hash(t, h, "correct horse"); other == encoded {
+				t.Error("Hash() returned the same hash twice: salt is not random")
+			}
+
+			for password, want := range map[string]bool{"correct horse": true, "correct horsE": false, "": false} {
+				got, err := h.Verify(password, encoded)
+				if err != nil || got != want {
+					t.Errorf("Verify(%q) = %v, %v, want %v", password, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyAcceptsEitherFormat(t *testing.T) {
+	bcryptHash := hash(t, newHasher(t, testBcrypt), "secret")
+	argonHash := hash(t, newHasher(t, testArgon), "secret")
+
+	// Смена алгоритма в конфиге не ломает вход со старыми хэшами
+	for _, h := range []*Hasher{newHasher(t, testBcrypt), newHasher(t, testArgon)} {
+		for _, encoded := range []string{bcryptHash, argonHash} {
+			if ok, err := h.Verify("secret", encoded); !ok || err != nil {
+				t.Errorf("%s: Verify(%.10s...) = %v, %v", h.algorithm, encoded, ok, err)
+			}
+		}
+	}
+}
+
+func TestVerifyRejectsMalformedHashes(t *testing.T) {
+	h := newHasher(t, testArgon)
+	valid := hash(t, h, "secret")
+	parts := strings.Split(valid, "$")
+
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr bool
+	}{
+		{"empty hash", "", false},
+		{"unknown format", "plain-text", true},
+		{"wrong version", strings.Replace(valid, "v=19", "v=16", 1), true},
+		{"bad parameters", strings.Replace(valid, parts[3], "m=x", 1), true},
+		{"bad salt", strings.Replace(valid, parts[4], "!!!", 1), true},
+		{"empty key", strings.TrimSuffix(valid, parts[5]), true},
+		{"missing part", strings.Join(parts[:5], "$"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := h.Verify("secret", tt.encoded)
+			if ok {
+				t.Error("Verify() accepted a malformed hash")
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := h.Verify("secret", "plain-text"); !errors.Is(err, ErrUnknownHashFormat) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrUnknownHashFormat)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	bcryptHasher := newHasher(t, testBcrypt)
+	argonHasher := newHasher(t, testArgon)
+
+	strongerBcrypt := testBcrypt
+	strongerBcrypt.BcryptCost++
+	strongerArgon := testArgon
+	strongerArgon.Argon2.Iterations++
+	longerKey := testArgon
+	longerKey.Argon2.KeyLength = 64
+
+	bcryptHash := hash(t, bcryptHasher, "secret")
+	argonHash := hash(t, argonHasher, "secret")
+
+	tests := []struct {
+		name    string
+		hasher  *Hasher
+		encoded string
+		want    bool
+	}{
+		{"same bcrypt cost", bcryptHasher, bcryptHash, false},
+		{"bcrypt cost changed", newHasher(t, strongerBcrypt), bcryptHash, true},
+		{"same argon2id parameters", argonHasher, argonHash, false},
+		{"argon2id iterations changed", newHasher(t, strongerArgon), argonHash, true},
+		{"argon2id key length changed", newHasher(t, longerKey), argonHash, true},
+		{"bcrypt to argon2id", argonHasher, bcryptHash, true},
+		{"argon2id to bcrypt", bcryptHasher, argonHash, true},
+		{"unknown format", argonHasher, "plain-text", true},
+		{"malformed argon2id", argonHasher, "$argon2id$v=19$broken", true},
+		{"account without password", argonHasher, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.NeedsRehash(tt.encoded); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
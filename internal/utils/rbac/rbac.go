@@ -0,0 +1,43 @@
+package rbac
+
+// Роли пользователей (хранятся в users.roles)
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Области доступа (scopes), которые проверяет RequireScope
+const (
+	ScopeProfileRead  = "profile:read"
+	ScopeProfileWrite = "profile:write"
+	ScopeAdmin        = "admin"
+)
+
+// roleScopes области доступа, которые дает каждая роль
+var roleScopes = map[string][]string{
+	RoleUser:  {ScopeProfileRead, ScopeProfileWrite},
+	RoleAdmin: {ScopeProfileRead, ScopeProfileWrite, ScopeAdmin},
+}
+
+// IsRole сообщает, что role - известная роль
+func IsRole(role string) bool {
+	_, ok := roleScopes[role]
+	return ok
+}
+
+// ScopesForRoles возвращает объединение областей доступа ролей без повторов
+func ScopesForRoles(roles []string) []string {
+	seen := make(map[string]bool)
+	scopes := []string{}
+
+	for _, role := range roles {
+		for _, scope := range roleScopes[role] {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	return scopes
+}
@@ -0,0 +1,179 @@
+package recommend
+
+import (
+	"math"
+	"passion-pals-backend/internal/config"
+	models "passion-pals-backend/internal/models"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Breakdown вклад каждой компоненты в итоговую оценку (значение компоненты, умноженное на вес)
+type Breakdown struct {
+	SharedInterests float64 `json:"shared_interests"`
+	Preferences     float64 `json:"preferences"`
+	Activity        float64 `json:"activity"`
+	Completeness    float64 `json:"completeness"`
+}
+
+// Result анкета кандидата с оценкой
+type Result struct {
+	Profile         *models.UserProfile
+	Score           float64
+	Breakdown       Breakdown
+	SharedInterests []string // Общие интересы (если кандидат их не скрыл)
+}
+
+// Ranker ранжирует кандидатов для пользователя. Поля, скрытые кандидатом, в оценке
+// не участвуют, чтобы их нельзя было вычислить по разбивке оценки.
+type Ranker struct {
+	cfg config.RecommendationsConfig
+}
+
+func New(cfg config.RecommendationsConfig) *Ranker {
+	return &Ranker{cfg: cfg}
+}
+
+// Rank оценивает кандидатов для viewer и возвращает limit лучших по убыванию оценки
+func (r *Ranker) Rank(viewer *models.UserProfile, candidates []*models.UserProfile, limit int) []Result {
+	now := time.Now()
+
+	results := make([]Result, 0, len(candidates))
+	for _, candidate := range candidates {
+		results = append(results, r.score(viewer, candidate, now))
+	}
+
+	// При равной оценке выше более свежая анкета, затем меньший id - порядок стабилен между запросами
+	slices.SortFunc(results, func(a, b Result) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		if !a.Profile.UpdatedAt.Equal(b.Profile.UpdatedAt) {
+			return b.Profile.UpdatedAt.Compare(a.Profile.UpdatedAt)
+		}
+		return a.Profile.ID - b.Profile.ID
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+func (r *Ranker) score(viewer, candidate *models.UserProfile, now time.Time) Result {
+	weights := r.cfg.Weights
+
+	shared := sharedInterests(viewer, candidate)
+
+	breakdown := Breakdown{
+		SharedInterests: round(weights.SharedInterests * interestsScore(viewer, candidate, len(shared))),
+		Preferences:     round(weights.Preferences * r.preferencesScore(viewer, candidate)),
+		Activity:        round(weights.Activity * r.activityScore(candidate, now)),
+		Completeness:    round(weights.Completeness * completenessScore(candidate)),
+	}
+
+	return Result{
+		Profile:         candidate,
+		Score:           round(breakdown.SharedInterests + breakdown.Preferences + breakdown.Activity + breakdown.Completeness),
+		Breakdown:       breakdown,
+		SharedInterests: shared,
+	}
+}
+
+// sharedInterests общие интересы пользователя и кандидата
+func sharedInterests(viewer, candidate *models.UserProfile) []string {
+	shared := []string{}
+	if candidate.IsHidden("interests") {
+		return shared
+	}
+
+	for _, interest := range candidate.Interests {
+		if slices.Contains(viewer.Interests, interest) {
+			shared = append(shared, interest)
+		}
+	}
+
+	return shared
+}
+
+// interestsScore доля общих интересов от меньшего из двух списков
+func interestsScore(viewer, candidate *models.UserProfile, shared int) float64 {
+	smaller := min(len(viewer.Interests), len(candidate.Interests))
+	if smaller == 0 {
+		return 0
+	}
+
+	return float64(shared) / float64(smaller)
+}
+
+// preferencesScore взаимное совпадение по полу (looking_for одного - пол другого)
+// и близость по возрасту
+func (r *Ranker) preferencesScore(viewer, candidate *models.UserProfile) float64 {
+	gender := (wants(viewer.LookingFor, candidate.Gender, candidate.IsHidden("gender")) +
+		wants(candidate.LookingFor, viewer.Gender, candidate.IsHidden("looking_for"))) / 2
+
+	age := 0.0
+	if !candidate.IsHidden("age") && viewer.Age > 0 && candidate.Age > 0 && r.cfg.MaxAgeGap > 0 {
+		gap := math.Abs(float64(viewer.Age - candidate.Age))
+		age = math.Max(0, 1-gap/float64(r.cfg.MaxAgeGap))
+	}
+
+	return (gender + age) / 2
+}
+
+// wants 1 - предпочтение совпадает с полом, 0 - не совпадает,
+// 0.5 - предпочтение не указано или скрыто
+func wants(lookingFor, gender string, hidden bool) float64 {
+	if hidden || strings.TrimSpace(lookingFor) == "" || gender == "" {
+		return 0.5
+	}
+
+	if strings.EqualFold(strings.TrimSpace(lookingFor), gender) {
+		return 1
+	}
+
+	return 0
+}
+
+// activityScore экспоненциально убывает с момента последнего обновления анкеты
+func (r *Ranker) activityScore(candidate *models.UserProfile, now time.Time) float64 {
+	if r.cfg.ActivityHalfLife <= 0 {
+		return 0
+	}
+
+	since := now.Sub(candidate.UpdatedAt)
+	if since < 0 {
+		since = 0
+	}
+
+	return math.Pow(0.5, since.Hours()/r.cfg.ActivityHalfLife.Hours())
+}
+
+// completenessScore доля заполненных и не скрытых полей анкеты
+func completenessScore(candidate *models.UserProfile) float64 {
+	fields := map[string]bool{
+		"avatar_url":  candidate.AvatarUrl != "",
+		"about_me":    candidate.AboutMe != "",
+		"gender":      candidate.Gender != "",
+		"looking_for": candidate.LookingFor != "",
+		"interests":   len(candidate.Interests) > 0,
+	}
+
+	filled := 0
+	for field, ok := range fields {
+		if ok && !candidate.IsHidden(field) {
+			filled++
+		}
+	}
+
+	return float64(filled) / float64(len(fields))
+}
+
+func round(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
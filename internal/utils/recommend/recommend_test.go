@@ -0,0 +1,239 @@
+package recommend
+
+import (
+	"passion-pals-backend/internal/config"
+	models "passion-pals-backend/internal/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestRanker() *Ranker {
+	return New(config.RecommendationsConfig{
+		Weights: config.RecommendationWeights{
+			SharedInterests: 0.4,
+			Preferences:     0.3,
+			Activity:        0.2,
+			Completeness:    0.1,
+		},
+		MaxAgeGap:        10,
+		ActivityHalfLife: 72 * time.Hour,
+	})
+}
+
+func TestRankOrder(t *testing.T) {
+	now := time.Now()
+	viewer := &models.UserProfile{ID: 1, Age: 25, Gender: "male", LookingFor: "female", Interests: []string{"chess", "hiking", "jazz"}}
+
+	candidates := []*models.UserProfile{
+		{ID: 2, Age: 40, Gender: "male", LookingFor: "male", UpdatedAt: now.Add(-30 * 24 * time.Hour)},
+		{ID: 3, Age: 25, Gender: "female", LookingFor: "male", Interests: []string{"chess", "hiking", "jazz"}, AboutMe: "hi", AvatarUrl: "a", UpdatedAt: now},
+		{ID: 4, Age: 27, Gender: "female", LookingFor: "male", Interests: []string{"chess"}, UpdatedAt: now.Add(-72 * time.Hour)},
+	}
+
+	results := newTestRanker().Rank(viewer, candidates, 10)
+
+	var ids []int
+	for _, result := range results {
+		ids = append(ids, result.Profile.ID)
+	}
+	if !slices.Equal(ids, []int{3, 4, 2}) {
+		t.Fatalf("order = %v, want [3 4 2]", ids)
+	}
+
+	for i := 1; i < len(results); i++ {
+		if results[i].Score > results[i-1].Score {
+			t.Errorf("results not sorted by score: %v > %v", results[i].Score, results[i-1].Score)
+		}
+	}
+}
+
+func TestRankLimit(t *testing.T) {
+	viewer := &models.UserProfile{ID: 1}
+	candidates := []*models.UserProfile{{ID: 2}, {ID: 3}, {ID: 4}}
+
+	if got := newTestRanker().Rank(viewer, candidates, 2); len(got) != 2 {
+		t.Errorf("len = %d, want 2", len(got))
+	}
+	if got := newTestRanker().Rank(viewer, candidates, 10); len(got) != 3 {
+		t.Errorf("len = %d, want 3", len(got))
+	}
+}
+
+// При равной оценке выше более свежая анкета, затем меньший id
+func TestRankTieBreak(t *testing.T) {
+	updated := time.Now().Add(-time.Hour)
+	viewer := &models.UserProfile{ID: 1}
+
+	tests := []struct {
+		name       string
+		candidates []*models.UserProfile
+		want       []int
+	}{
+		{
+			name:       "same update time - lower id first",
+			candidates: []*models.UserProfile{{ID: 9, UpdatedAt: updated}, {ID: 5, UpdatedAt: updated}, {ID: 7, UpdatedAt: updated}},
+			want:       []int{5, 7, 9},
+		},
+		{
+			// Без периода полураспада активность не влияет на оценку, порядок задает только время обновления
+			name:       "fresher profile first",
+			candidates: []*models.UserProfile{{ID: 5, UpdatedAt: updated.Add(-time.Hour)}, {ID: 9, UpdatedAt: updated}},
+			want:       []int{9, 5},
+		},
+	}
+
+	ranker := newTestRanker()
+	ranker.cfg.ActivityHalfLife = 0
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []int
+			for _, result := range ranker.Rank(viewer, tt.candidates, 10) {
+				ids = append(ids, result.Profile.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("order = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+// Скрытые кандидатом поля не должны влиять на оценку
+func TestScoreHiddenFields(t *testing.T) {
+	now := time.Now()
+	viewer := &models.UserProfile{ID: 1, Age: 25, Gender: "male", LookingFor: "female", Interests: []string{"chess", "jazz"}}
+
+	base := models.UserProfile{
+		ID: 2, Age: 25, Gender: "female", LookingFor: "male",
+		Interests: []string{"chess", "jazz"}, AboutMe: "hi", AvatarUrl: "a", UpdatedAt: now,
+	}
+
+	tests := []struct {
+		name   string
+		hidden []string
+		check  func(t *testing.T, got, full Result)
+	}{
+		{
+			name:   "interests",
+			hidden: []string{"interests"},
+			check: func(t *testing.T, got, full Result) {
+				if got.Breakdown.SharedInterests != 0 || len(got.SharedInterests) != 0 {
+					t.Errorf("hidden interests leaked: %v %v", got.Breakdown.SharedInterests, got.SharedInterests)
+				}
+			},
+		},
+		{
+			name:   "age",
+			hidden: []string{"age"},
+			check: func(t *testing.T, got, full Result) {
+				if got.Breakdown.Preferences >= full.Breakdown.Preferences {
+					t.Errorf("hidden age still counted: %v >= %v", got.Breakdown.Preferences, full.Breakdown.Preferences)
+				}
+			},
+		},
+		{
+			// Скрытый пол считается неизвестным (0.5), а не совпадением
+			name:   "gender",
+			hidden: []string{"gender"},
+			check: func(t *testing.T, got, full Result) {
+				if got.Breakdown.Preferences >= full.Breakdown.Preferences {
+					t.Errorf("hidden gender still counted: %v >= %v", got.Breakdown.Preferences, full.Breakdown.Preferences)
+				}
+			},
+		},
+		{
+			name:   "about_me",
+			hidden: []string{"about_me"},
+			check: func(t *testing.T, got, full Result) {
+				if got.Breakdown.Completeness >= full.Breakdown.Completeness {
+					t.Errorf("hidden about_me still counted: %v >= %v", got.Breakdown.Completeness, full.Breakdown.Completeness)
+				}
+			},
+		},
+	}
+
+	ranker := newTestRanker()
+	full := ranker.score(viewer, &base, now)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidate := base
+			candidate.HiddenFields = tt.hidden
+			tt.check(t, ranker.score(viewer, &candidate, now), full)
+		})
+	}
+}
+
+func TestScoreComponents(t *testing.T) {
+	now := time.Now()
+	ranker := newTestRanker()
+
+	tests := []struct {
+		name      string
+		viewer    models.UserProfile
+		candidate models.UserProfile
+		want      Breakdown
+	}{
+		{
+			name:      "perfect match",
+			viewer:    models.UserProfile{Age: 30, Gender: "male", LookingFor: "female", Interests: []string{"chess", "jazz"}},
+			candidate: models.UserProfile{Age: 30, Gender: "female", LookingFor: "male", Interests: []string{"chess", "jazz"}, AboutMe: "hi", AvatarUrl: "a", UpdatedAt: now},
+			want:      Breakdown{SharedInterests: 0.4, Preferences: 0.3, Activity: 0.2, Completeness: 0.1},
+		},
+		{
+			// Доля общих интересов считается от меньшего списка; age gap 5 из 10 дает 0.5
+			name:      "partial",
+			viewer:    models.UserProfile{Age: 30, Gender: "male", LookingFor: "female", Interests: []string{"chess", "jazz", "hiking", "go"}},
+			candidate: models.UserProfile{Age: 35, Gender: "male", LookingFor: "", Interests: []string{"chess", "art"}, UpdatedAt: now.Add(-72 * time.Hour)},
+			want:      Breakdown{SharedInterests: 0.2, Preferences: 0.1125, Activity: 0.1, Completeness: 0.04},
+		},
+		{
+			name:      "empty profiles",
+			viewer:    models.UserProfile{},
+			candidate: models.UserProfile{UpdatedAt: now},
+			want:      Breakdown{SharedInterests: 0, Preferences: 0.075, Activity: 0.2, Completeness: 0},
+		},
+		{
+			name:      "age gap beyond max",
+			viewer:    models.UserProfile{Age: 20},
+			candidate: models.UserProfile{Age: 45, UpdatedAt: now},
+			want:      Breakdown{SharedInterests: 0, Preferences: 0.075, Activity: 0.2, Completeness: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ranker.score(&tt.viewer, &tt.candidate, now)
+			if got.Breakdown != tt.want {
+				t.Errorf("breakdown = %+v, want %+v", got.Breakdown, tt.want)
+			}
+			sum := round(tt.want.SharedInterests + tt.want.Preferences + tt.want.Activity + tt.want.Completeness)
+			if got.Score != sum {
+				t.Errorf("score = %v, want %v", got.Score, sum)
+			}
+		})
+	}
+}
+
+func TestWants(t *testing.T) {
+	tests := []struct {
+		lookingFor string
+		gender     string
+		hidden     bool
+		want       float64
+	}{
+		{"female", "female", false, 1},
+		{" Female ", "female", false, 1},
+		{"female", "male", false, 0},
+		{"", "male", false, 0.5},
+		{"female", "", false, 0.5},
+		{"female", "female", true, 0.5},
+	}
+
+	for _, tt := range tests {
+		if got := wants(tt.lookingFor, tt.gender, tt.hidden); got != tt.want {
+			t.Errorf("wants(%q, %q, %v) = %v, want %v", tt.lookingFor, tt.gender, tt.hidden, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"passion-pals-backend/internal/repository"
+	"sync"
+	"time"
+)
+
+// syncOverlap перекрытие окон синхронизации на случай расхождения часов между инстансами
+const syncOverlap = 5 * time.Second
+
+// List список отозванных токенов. Источник истины - Postgres, а проверка
+// на каждом запросе идет по копии в памяти, которая периодически
+// догружается из базы. Отзывы, сделанные этим инстансом, видны сразу,
+// сделанные другими - не позже чем через syncInterval.
+type List struct {
+	log           *slog.Logger
+	repo          *repository.Repository
+	syncInterval  time.Duration
+	purgeInterval time.Duration
+
+	mu       sync.RWMutex
+	tokens   map[string]time.Time // jti -> exp
+	users    map[int]userCutoff
+	lastSync time.Time
+}
+
+type userCutoff struct {
+	revokedBefore time.Time
+	expiresAt     time.Time
+}
+
+func New(log *slog.Logger, repo *repository.Repository, syncInterval, purgeInterval time.Duration) *List {
+	return &List{
+		log:           log,
+		repo:          repo,
+		syncInterval:  syncInterval,
+		purgeInterval: purgeInterval,
+		tokens:        make(map[string]time.Time),
+		users:         make(map[int]userCutoff),
+	}
+}
+
+// RevokeToken отзывает один access-токен
+func (l *List) RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	if err := l.repo.RevokeToken(ctx, jti, userID, expiresAt, time.Now()); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.tokens[jti] = expiresAt
+	l.mu.Unlock()
+
+	return nil
+}
+
+// RevokeUser отзывает все access-токены пользователя, выпущенные до текущего момента.
+// tokenTTL - максимальный срок жизни access-токена: после него запись не нужна.
+func (l *List) RevokeUser(ctx context.Context, userID int, tokenTTL time.Duration) error {
+	now := time.Now()
+	cutoff := userCutoff{
+		revokedBefore: now,
+		expiresAt:     now.Add(tokenTTL),
+	}
+
+	if err := l.repo.RevokeUserTokens(ctx, userID, cutoff.revokedBefore, cutoff.expiresAt); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.users[userID] = cutoff
+	l.mu.Unlock()
+
+	return nil
+}
+
+// IsRevoked проверяет, отозван ли токен с идентификатором jti, выпущенный issuedAt
+func (l *List) IsRevoked(jti string, userID int, issuedAt time.Time) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if _, ok := l.tokens[jti]; ok {
+		return true
+	}
+
+	// iat хранится с точностью до секунды, поэтому момент отзыва сравнивается с той же
+	// точностью: токен, выпущенный в ту же секунду после "выхода везде", остается рабочим
+	if cutoff, ok := l.users[userID]; ok && issuedAt.Before(cutoff.revokedBefore.Truncate(time.Second)) {
+		return true
+	}
+
+	return false
+}
+
+// Load загружает список из базы. Вызывается при запуске до приема запросов:
+// без загруженного списка отозванные токены принимались бы как действующие.
+func (l *List) Load(ctx context.Context) error {
+	if err := l.sync(ctx); err != nil {
+		return fmt.Errorf("failed to load revocation list: %w", err)
+	}
+
+	return nil
+}
+
+// Run поддерживает загруженный через Load список в актуальном состоянии до отмены ctx
+func (l *List) Run(ctx context.Context) {
+	const op = "revocation.Run"
+
+	log := l.log.With(slog.String("op", op))
+
+	syncTicker := time.NewTicker(l.syncInterval)
+	defer syncTicker.Stop()
+
+	purgeTicker := time.NewTicker(l.purgeInterval)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-syncTicker.C:
+			if err := l.sync(ctx); err != nil {
+				log.Error("failed to sync revocation list", slog.String("error", err.Error()))
+			}
+		case <-purgeTicker.C:
+			purged, err := l.repo.PurgeExpiredRevocations(ctx, time.Now())
+			if err != nil {
+				log.Error("failed to purge expired revocations", slog.String("error", err.Error()))
+				continue
+			}
+			l.purgeMemory(time.Now())
+			log.Debug("expired revocations purged", slog.Int64("count", purged))
+		}
+	}
+}
+
+// sync догружает записи, появившиеся после предыдущей синхронизации
+func (l *List) sync(ctx context.Context) error {
+	l.mu.RLock()
+	since := l.lastSync.Add(-syncOverlap)
+	l.mu.RUnlock()
+
+	startedAt := time.Now()
+
+	tokens, err := l.repo.GetRevokedTokensSince(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	users, err := l.repo.GetUserTokenRevocationsSince(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, token := range tokens {
+		l.tokens[token.JTI] = token.ExpiresAt
+	}
+
+	for _, user := range users {
+		if current, ok := l.users[user.UserID]; ok && current.revokedBefore.After(user.RevokedBefore) {
+			continue
+		}
+		l.users[user.UserID] = userCutoff{
+			revokedBefore: user.RevokedBefore,
+			expiresAt:     user.ExpiresAt,
+		}
+	}
+
+	l.lastSync = startedAt
+
+	return nil
+}
+
+func (l *List) purgeMemory(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for jti, expiresAt := range l.tokens {
+		if !expiresAt.After(now) {
+			delete(l.tokens, jti)
+		}
+	}
+
+	for userID, cutoff := range l.users {
+		if !cutoff.expiresAt.After(now) {
+			delete(l.users, userID)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package revocation
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestIsRevoked(t *testing.T) {
+	revokedAt := time.Date(2026, 10, 18, 12, 0, 30, 400_000_000, time.UTC)
+
+	l := New(slog.Default(), nil, time.Minute, time.Hour)
+	l.tokens["revoked-jti"] = revokedAt.Add(time.Hour)
+	l.users[1] = userCutoff{revokedBefore: revokedAt, expiresAt: revokedAt.Add(time.Hour)}
+
+	tests := []struct {
+		name     string
+		jti      string
+		userID   int
+		issuedAt time.Time
+		want     bool
+	}{
+		{"revoked token", "revoked-jti", 2, revokedAt, true},
+		{"issued before cutoff", "a", 1, revokedAt.Add(-time.Second).Truncate(time.Second), true},
+		{"issued in the same second", "b", 1, revokedAt.Truncate(time.Second), false},
+		{"issued after cutoff", "c", 1, revokedAt.Add(time.Second).Truncate(time.Second), false},
+		{"other user", "d", 2, revokedAt.Add(-time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.IsRevoked(tt.jti, tt.userID, tt.issuedAt); got != tt.want {
+				t.Errorf("IsRevoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
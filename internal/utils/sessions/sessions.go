@@ -0,0 +1,103 @@
+package sessions
+
+import (
+	"context"
+	"log/slog"
+	"passion-pals-backend/internal/repository"
+	"sync"
+	"time"
+)
+
+// Tracker отмечает активность сессий и проверяет, что сессия не отозвана.
+// Чтобы не писать в базу на каждый запрос, сессия, проверенная этим
+// инстансом, считается активной в течение touchInterval. Отзыв сессии
+// дополнительно отзывает ее текущий access-токен через список отозванных,
+// поэтому задержка не дает пользоваться отозванной сессией.
+type Tracker struct {
+	log           *slog.Logger
+	repo          *repository.Repository
+	touchInterval time.Duration
+	purgeInterval time.Duration
+
+	mu      sync.Mutex
+	touched map[string]time.Time // id сессии -> время последней записи в базу
+}
+
+func New(log *slog.Logger, repo *repository.Repository, touchInterval, purgeInterval time.Duration) *Tracker {
+	return &Tracker{
+		log:           log,
+		repo:          repo,
+		touchInterval: touchInterval,
+		purgeInterval: purgeInterval,
+		touched:       make(map[string]time.Time),
+	}
+}
+
+// Touch отмечает активность сессии и сообщает, активна ли она
+func (t *Tracker) Touch(ctx context.Context, sessionID string) (bool, error) {
+	now := time.Now()
+
+	t.mu.Lock()
+	last, ok := t.touched[sessionID]
+	t.mu.Unlock()
+
+	if ok && now.Sub(last) < t.touchInterval {
+		return true, nil
+	}
+
+	active, err := t.repo.TouchSession(ctx, sessionID, now)
+	if err != nil {
+		return false, err
+	}
+
+	t.mu.Lock()
+	if active {
+		t.touched[sessionID] = now
+	} else {
+		delete(t.touched, sessionID)
+	}
+	t.mu.Unlock()
+
+	return active, nil
+}
+
+// Forget сбрасывает кэш сессии, чтобы следующая проверка пошла в базу
+func (t *Tracker) Forget(sessionID string) {
+	t.mu.Lock()
+	delete(t.touched, sessionID)
+	t.mu.Unlock()
+}
+
+// Run периодически чистит кэш и удаляет из базы истекшие сессии до отмены ctx
+func (t *Tracker) Run(ctx context.Context) {
+	const op = "sessions.Run"
+
+	log := t.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(t.purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+
+			t.mu.Lock()
+			for id, last := range t.touched {
+				if now.Sub(last) >= t.touchInterval {
+					delete(t.touched, id)
+				}
+			}
+			t.mu.Unlock()
+
+			purged, err := t.repo.PurgeSessions(ctx, now)
+			if err != nil {
+				log.Error("failed to purge sessions", slog.String("error", err.Error()))
+				continue
+			}
+			log.Debug("expired sessions purged", slog.Int64("count", purged))
+		}
+	}
+}
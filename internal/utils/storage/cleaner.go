@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"passion-pals-backend/internal/repository"
+	"time"
+)
+
+// cleanupBatchSize сколько файлов удаляется за один проход по очереди
+const cleanupBatchSize = 100
+
+// Cleaner удаляет из хранилища файлы удаленных фотографий. Очередь media_deletions
+// заполняет триггер на profile_photos, поэтому файлы удаляются и после удаления фотографии
+// пользователем, и после окончательного удаления учетной записи. Файл, который не удалось
+// удалить, остается в очереди до следующего запуска.
+type Cleaner struct {
+	log      *slog.Logger
+	repo     *repository.Repository
+	storage  Storage
+	interval time.Duration
+}
+
+func NewCleaner(log *slog.Logger, repo *repository.Repository, storage Storage, interval time.Duration) *Cleaner {
+	return &Cleaner{
+		log:      log,
+		repo:     repo,
+		storage:  storage,
+		interval: interval,
+	}
+}
+
+// Run периодически удаляет файлы до отмены ctx
+func (c *Cleaner) Run(ctx context.Context) {
+	const op = "storage.Run"
+
+	log := c.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.clean(ctx, log)
+		}
+	}
+}
+
+// clean обрабатывает очередь пачками, пока она не закончится или пока файлы не перестанут удаляться
+func (c *Cleaner) clean(ctx context.Context, log *slog.Logger) {
+	var total int
+
+	for ctx.Err() == nil {
+		keys, err := c.repo.GetMediaDeletions(ctx, cleanupBatchSize)
+		if err != nil {
+			log.Error("failed to get media deletions", slog.String("error", err.Error()))
+			return
+		}
+
+		var deleted []string
+		for _, key := range keys {
+			if err := c.storage.Delete(ctx, key); err != nil {
+				log.Error("failed to delete media", slog.String("key", key), slog.String("error", err.Error()))
+				continue
+			}
+			deleted = append(deleted, key)
+		}
+
+		if len(deleted) > 0 {
+			if err := c.repo.CompleteMediaDeletions(ctx, deleted); err != nil {
+				log.Error("failed to complete media deletions", slog.String("error", err.Error()))
+				return
+			}
+			total += len(deleted)
+		}
+
+		if len(keys) < cleanupBatchSize || len(deleted) < len(keys) {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Info("media deleted", slog.Int("count", total))
+	}
+}
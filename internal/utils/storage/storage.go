@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"passion-pals-backend/internal/config"
+)
+
+// Storage хранилище загруженных файлов
+type Storage interface {
+	// Put сохраняет объект под ключом key
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete удаляет объект; отсутствие объекта ошибкой не считается
+	Delete(ctx context.Context, key string) error
+	// URL возвращает адрес, по которому объект доступен клиентам
+	URL(key string) string
+}
+
+// New создает Storage по настройкам cfg.Driver: local или s3
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "local", "":
+		return NewLocal(cfg.Local)
+	case "s3":
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,183 @@
+package throttle
+
+import (
+	"context"
+	"log/slog"
+	"passion-pals-backend/internal/config"
+	"passion-pals-backend/internal/repository"
+	"strings"
+	"time"
+)
+
+// Limiter ограничивает число неудачных попыток входа по учетной записи и по IP.
+// После порога неудач вход блокируется, и каждая следующая неудача
+// удваивает время блокировки (но не больше MaxDelay).
+// Состояние хранится в Postgres и общее для всех инстансов.
+type Limiter struct {
+	log  *slog.Logger
+	repo *repository.Repository
+	cfg  config.LockoutConfig
+}
+
+func New(log *slog.Logger, repo *repository.Repository, cfg config.LockoutConfig) *Limiter {
+	return &Limiter{
+		log:  log,
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+// Check возвращает оставшееся время блокировки или 0, если вход разрешен
+func (l *Limiter) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	lockedUntil, err := l.repo.GetLoginLockedUntil(ctx, []string{accountKey(email), ipKey(ip)})
+	if err != nil {
+		return 0, err
+	}
+
+	if lockedUntil == nil {
+		return 0, nil
+	}
+
+	return time.Until(*lockedUntil), nil
+}
+
+// Fail учитывает неудачную попытку входа
+func (l *Limiter) Fail(ctx context.Context, email, ip string) error {
+	windowStart := time.Now().Add(-l.cfg.Window)
+
+	if err := l.fail(ctx, accountKey(email), windowStart, l.cfg.MaxAccountFailures); err != nil {
+		return err
+	}
+
+	return l.fail(ctx, ipKey(ip), windowStart, l.cfg.MaxIPFailures)
+}
+
+// Succeed сбрасывает счетчик учетной записи после успешного входа.
+// Счетчик IP не сбрасывается: иначе с одного адреса можно было бы
+// перебирать пароли чужих учетных записей, периодически входя в свою.
+func (l *Limiter) Succeed(ctx context.Context, email string) error {
+	return l.repo.ResetLoginFailures(ctx, accountKey(email))
+}
+
+// MagicLink учитывает запрос ссылки для входа на адрес email. Возвращает оставшееся
+// время блокировки или 0, если ссылку можно отправить. После MaxMagicLinks запросов
+// за Window следующие запросы на этот адрес отклоняются до конца окна.
+func (l *Limiter) MagicLink(ctx context.Context, email string) (time.Duration, error) {
+	return l.request(ctx, quota{magicLinkKey(email), l.cfg.MaxMagicLinks})
+}
+
+// Email учитывает запрос письма вида kind ("verify", "reset") на адрес email с IP ip.
+// Возвращает оставшееся время блокировки или 0, если письмо можно отправить. Лимиты:
+// MaxEmailRequests на адрес для каждого вида и MaxIPEmailRequests на IP для всех видов за Window.
+func (l *Limiter) Email(ctx context.Context, kind, email, ip string) (time.Duration, error) {
+	return l.request(ctx,
+		quota{emailKey(kind, email), l.cfg.MaxEmailRequests},
+		quota{emailIPKey(ip), l.cfg.MaxIPEmailRequests},
+	)
+}
+
+// Run периодически удаляет устаревшие счетчики до отмены ctx
+func (l *Limiter) Run(ctx context.Context) {
+	const op = "throttle.Run"
+
+	log := l.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := l.repo.PurgeLoginAttempts(ctx, time.Now().Add(-l.cfg.Window))
+			if err != nil {
+				log.Error("failed to purge login attempts", slog.String("error", err.Error()))
+				continue
+			}
+			log.Debug("stale login attempts purged", slog.Int64("count", purged))
+		}
+	}
+}
+
+// quota ограничение числа запросов по ключу за Window
+type quota struct {
+	key string
+	max int
+}
+
+// request учитывает запрос по всем квотам. Если хотя бы одна исчерпана, возвращает
+// оставшееся время блокировки; квота, достигшая max, блокируется до конца окна.
+func (l *Limiter) request(ctx context.Context, quotas ...quota) (time.Duration, error) {
+	keys := make([]string, 0, len(quotas))
+	for _, q := range quotas {
+		keys = append(keys, q.key)
+	}
+
+	lockedUntil, err := l.repo.GetLoginLockedUntil(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+	if lockedUntil != nil {
+		return time.Until(*lockedUntil), nil
+	}
+
+	for _, q := range quotas {
+		requests, err := l.repo.AddLoginFailure(ctx, q.key, time.Now().Add(-l.cfg.Window))
+		if err != nil {
+			return 0, err
+		}
+
+		if requests >= q.max {
+			if err := l.repo.LockLogin(ctx, q.key, time.Now().Add(l.cfg.Window)); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+func (l *Limiter) fail(ctx context.Context, key string, windowStart time.Time, threshold int) error {
+	failures, err := l.repo.AddLoginFailure(ctx, key, windowStart)
+	if err != nil {
+		return err
+	}
+
+	if failures < threshold {
+		return nil
+	}
+
+	return l.repo.LockLogin(ctx, key, time.Now().Add(l.lockDuration(failures-threshold)))
+}
+
+// lockDuration возвращает BaseDelay * 2^excess, ограниченное MaxDelay
+func (l *Limiter) lockDuration(excess int) time.Duration {
+	delay := l.cfg.BaseDelay
+
+	for i := 0; i < excess && delay < l.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, l.cfg.MaxDelay)
+}
+
+func accountKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func magicLinkKey(email string) string {
+	return "magic:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func emailKey(kind, email string) string {
+	return kind + ":" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func emailIPKey(ip string) string {
+	return "mail-ip:" + ip
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,171 @@
+package validation
+
+import (
+	"bufio"
+	"fmt"
+	"net/mail"
+	"os"
+	"passion-pals-backend/internal/config"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bcrypt учитывает только первые 72 байта пароля
+const maxPasswordBytes = 72
+
+// Policy правила регистрации: email, пароль, возраст, пол и имя пользователя
+type Policy struct {
+	minPasswordLength int
+	minAge            int
+	genders           map[string]bool
+	breached          map[string]struct{}
+}
+
+// NewPolicy создает политику и загружает список утекших паролей (по одному в строке)
+func NewPolicy(cfg config.RegistrationConfig) (*Policy, error) {
+	p := &Policy{
+		minPasswordLength: cfg.MinPasswordLength,
+		minAge:            cfg.MinAge,
+		genders:           make(map[string]bool, len(cfg.AllowedGenders)),
+		breached:          make(map[string]struct{}),
+	}
+
+	for _, gender := range cfg.AllowedGenders {
+		p.genders[strings.ToLower(gender)] = true
+	}
+
+	if cfg.BreachedPasswordsFile == "" {
+		return p, nil
+	}
+
+	file, err := os.Open(cfg.BreachedPasswordsFile)
+	if err != nil {
+		return nil, fmt.Errorf("validation: failed to open breached passwords file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.breached[strings.ToLower(line)] = struct{}{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("validation: failed to read breached passwords file: %w", err)
+	}
+
+	return p, nil
+}
+
+// NormalizeEmail приводит адрес к виду, в котором он хранится и сравнивается:
+// без пробелов по краям и в нижнем регистре
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Email проверяет синтаксис адреса. Допускается только "голый" адрес без имени.
+func (p *Policy) Email(errs *Errors, field, email string) {
+	if strings.TrimSpace(email) == "" {
+		errs.Add(field, CodeRequired, "Email is required")
+		return
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || !strings.Contains(addr.Address[strings.LastIndex(addr.Address, "@"):], ".") {
+		errs.Add(field, CodeInvalid, "Email is not valid")
+	}
+}
+
+// Password проверяет длину пароля и его отсутствие в списке утекших
+func (p *Policy) Password(errs *Errors, field, password string) {
+	if password == "" {
+		errs.Add(field, CodeRequired, "Password is required")
+		return
+	}
+
+	if utf8.RuneCountInString(password) < p.minPasswordLength {
+		errs.Add(field, CodeTooShort, fmt.Sprintf("Password must be at least %d characters long", p.minPasswordLength))
+		return
+	}
+
+	if len(password) > maxPasswordBytes {
+		errs.Add(field, CodeTooLong, fmt.Sprintf("Password must be at most %d bytes long", maxPasswordBytes))
+		return
+	}
+
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		errs.Add(field, CodeBreached, "Password is too common or has appeared in a data breach")
+	}
+}
+
+// BirthDate проверяет, что дата рождения указана, не в будущем и пользователь достиг минимального возраста
+func (p *Policy) BirthDate(errs *Errors, field string, birthDate time.Time) {
+	if birthDate.IsZero() {
+		errs.Add(field, CodeRequired, "Birth date is required")
+		return
+	}
+
+	now := time.Now()
+
+	if birthDate.After(now) {
+		errs.Add(field, CodeInFuture, "Birth date cannot be in the future")
+		return
+	}
+
+	if Age(birthDate, now) < p.minAge {
+		errs.Add(field, CodeTooYoung, fmt.Sprintf("You must be at least %d years old", p.minAge))
+	}
+}
+
+// Gender проверяет, что пол входит в допустимый набор
+func (p *Policy) Gender(errs *Errors, field, gender string) {
+	if strings.TrimSpace(gender) == "" {
+		errs.Add(field, CodeRequired, "Gender is required")
+		return
+	}
+
+	if !p.genders[strings.ToLower(gender)] {
+		errs.Add(field, CodeNotAllow, "Gender is not in the allowed set")
+	}
+}
+
+// Username проверяет длину и набор символов имени пользователя
+func (p *Policy) Username(errs *Errors, field, username string) {
+	if strings.TrimSpace(username) == "" {
+		errs.Add(field, CodeRequired, "Username is required")
+		return
+	}
+
+	length := utf8.RuneCountInString(username)
+	if length < 3 {
+		errs.Add(field, CodeTooShort, "Username must be at least 3 characters long")
+		return
+	}
+	if length > 32 {
+		errs.Add(field, CodeTooLong, "Username must be at most 32 characters long")
+		return
+	}
+
+	for _, r := range username {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.' {
+			errs.Add(field, CodeInvalid, "Username may contain only letters, digits, '_', '-' and '.'")
+			return
+		}
+	}
+}
+
+// Age возвращает число полных лет на момент now
+func Age(birthDate, now time.Time) int {
+	age := now.Year() - birthDate.Year()
+
+	if now.Month() < birthDate.Month() || (now.Month() == birthDate.Month() && now.Day() < birthDate.Day()) {
+		age--
+	}
+
+	return age
+}
@@ -0,0 +1,231 @@
+package validation
+
+import (
+	"os"
+	"passion-pals-backend/internal/config"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestPolicy(t *testing.T) *Policy {
+	t.Helper()
+
+	breached := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(breached, []byte("# популярные пароли\nPassword123\n\nqwertyuiop\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewPolicy(config.RegistrationConfig{
+		MinPasswordLength:     8,
+		MinAge:                18,
+		AllowedGenders:        []string{"Male", "female"},
+		BreachedPasswordsFile: breached,
+	})
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+
+	return p
+}
+
+// check вызывает проверку и возвращает код первой ошибки ("" - ошибок нет)
+func check(validate func(errs *Errors)) string {
+	var errs Errors
+	validate(&errs)
+
+	if errs.Empty() {
+		return ""
+	}
+
+	return errs[0].Code
+}
+
+func TestNewPolicyRejectsMissingBreachedFile(t *testing.T) {
+	_, err := NewPolicy(config.RegistrationConfig{BreachedPasswordsFile: filepath.Join(t.TempDir(), "missing.txt")})
+	if err == nil {
+		t.Error("NewPolicy() accepted a missing breached passwords file")
+	}
+}
+
+func TestEmail(t *testing.T) {
+	p := newTestPolicy(t)
+
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"user@example.com", ""},
+		{"first.last+tag@sub.example.org", ""},
+		{"", CodeRequired},
+		{"   ", CodeRequired},
+		{"user", CodeInvalid},
+		{"user@localhost", CodeInvalid},
+		{"User <user@example.com>", CodeInvalid},
+		{" user@example.com", CodeInvalid},
+		{"user@@example.com", CodeInvalid},
+	}
+
+	for _, tt := range tests {
+		if got := check(func(errs *Errors) { p.Email(errs, "email", tt.email) }); got != tt.want {
+			t.Errorf("Email(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	if got := NormalizeEmail("  Foo.Bar@Example.COM "); got != "foo.bar@example.com" {
+		t.Errorf("NormalizeEmail() = %q", got)
+	}
+}
+
+func TestPassword(t *testing.T) {
+	p := newTestPolicy(t)
+
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"valid", "correct horse battery", ""},
+		{"empty", "", CodeRequired},
+		{"too short", "abc1234", CodeTooShort},
+		{"short in runes", "пароль1", CodeTooShort},
+		{"longer than bcrypt accepts", strings.Repeat("a", maxPasswordBytes+1), CodeTooLong},
+		{"breached", "password123", CodeBreached},
+		{"breached, other case", "QWERTYUIOP", CodeBreached},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := check(func(errs *Errors) { p.Password(errs, "password", tt.password) }); got != tt.want {
+				t.Errorf("Password() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBirthDate(t *testing.T) {
+	p := newTestPolicy(t)
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		birthDate time.Time
+		want      string
+	}{
+		{"adult", now.AddDate(-30, 0, 0), ""},
+		{"exactly min age", now.AddDate(-18, 0, 0), ""},
+		{"one day before min age", now.AddDate(-18, 0, 1), CodeTooYoung},
+		{"missing", time.Time{}, CodeRequired},
+		{"in future", now.Add(time.Hour), CodeInFuture},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := check(func(errs *Errors) { p.BirthDate(errs, "birth_date", tt.birthDate) }); got != tt.want {
+				t.Errorf("BirthDate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAge(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		birthDate time.Time
+		now       time.Time
+		want      int
+	}{
+		{"birthday today", date(2000, time.May, 10), date(2026, time.May, 10), 26},
+		{"day before birthday", date(2000, time.May, 10), date(2026, time.May, 9), 25},
+		{"month before birthday", date(2000, time.May, 10), date(2026, time.April, 30), 25},
+		{"after birthday", date(2000, time.May, 10), date(2026, time.December, 1), 26},
+		{"leap day, common year", date(2004, time.February, 29), date(2026, time.February, 28), 21},
+		{"leap day, after", date(2004, time.February, 29), date(2026, time.March, 1), 22},
+		{"born today", date(2026, time.May, 10), date(2026, time.May, 10), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Age(tt.birthDate, tt.now); got != tt.want {
+				t.Errorf("Age() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGender(t *testing.T) {
+	p := newTestPolicy(t)
+
+	tests := []struct {
+		gender string
+		want   string
+	}{
+		{"male", ""},
+		{"MALE", ""},
+		{"female", ""},
+		{"", CodeRequired},
+		{"  ", CodeRequired},
+		{"other", CodeNotAllow},
+	}
+
+	for _, tt := range tests {
+		if got := check(func(errs *Errors) { p.Gender(errs, "gender", tt.gender) }); got != tt.want {
+			t.Errorf("Gender(%q) = %q, want %q", tt.gender, got, tt.want)
+		}
+	}
+}
+
+func TestUsername(t *testing.T) {
+	p := newTestPolicy(t)
+
+	tests := []struct {
+		username string
+		want     string
+	}{
+		{"alice", ""},
+		{"al.ice_99-x", ""},
+		{"Алиса", ""},
+		{"", CodeRequired},
+		{"ab", CodeTooShort},
+		{"абв", ""},
+		{strings.Repeat("a", 32), ""},
+		{strings.Repeat("a", 33), CodeTooLong},
+		{"alice smith", CodeInvalid},
+		{"alice@home", CodeInvalid},
+	}
+
+	for _, tt := range tests {
+		if got := check(func(errs *Errors) { p.Username(errs, "username", tt.username) }); got != tt.want {
+			t.Errorf("Username(%q) = %q, want %q", tt.username, got, tt.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var errs Errors
+	if !errs.Empty() {
+		t.Error("new Errors is not empty")
+	}
+
+	errs.Add("email", CodeRequired, "Email is required")
+	errs.Add("password", CodeTooShort, "Password is too short")
+
+	if errs.Empty() || !errs.Has("email") || !errs.Has("password") || errs.Has("username") {
+		t.Errorf("unexpected errors: %+v", errs)
+	}
+
+	response := errs.Response()
+	if response["error"] != "Validation failed" {
+		t.Errorf("response error = %v", response["error"])
+	}
+	if fields, ok := response["fields"].(Errors); !ok || len(fields) != 2 {
+		t.Errorf("response fields = %v", response["fields"])
+	}
+}
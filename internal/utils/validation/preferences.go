@@ -0,0 +1,45 @@
+package validation
+
+import (
+	"fmt"
+	"slices"
+)
+
+// maxPreferredAge верхняя граница возраста в предпочтениях подбора
+const maxPreferredAge = 120
+
+// AgeRange проверяет диапазон возраста в предпочтениях подбора: границы не младше
+// минимального возраста регистрации и не больше maxPreferredAge, min_age <= max_age
+func (p *Policy) AgeRange(errs *Errors, minField, maxField string, minAge, maxAge *int) {
+	valid := true
+	for _, bound := range []struct {
+		field string
+		age   *int
+	}{{minField, minAge}, {maxField, maxAge}} {
+		if bound.age != nil && (*bound.age < p.minAge || *bound.age > maxPreferredAge) {
+			errs.Add(bound.field, CodeInvalid, fmt.Sprintf("Age must be between %d and %d", p.minAge, maxPreferredAge))
+			valid = false
+		}
+	}
+
+	if valid && minAge != nil && maxAge != nil && *minAge > *maxAge {
+		errs.Add(minField, CodeInvalid, "Minimum age cannot be greater than maximum age")
+	}
+}
+
+// Genders проверяет, что все значения из допустимого набора полов
+func (p *Policy) Genders(errs *Errors, field string, genders []string) {
+	for _, gender := range genders {
+		if !p.genders[gender] {
+			errs.Add(field, CodeNotAllow, fmt.Sprintf("Gender %q is not in the allowed set", gender))
+			return
+		}
+	}
+}
+
+// OneOf проверяет, что непустое значение входит в allowed
+func (p *Policy) OneOf(errs *Errors, field, value string, allowed []string) {
+	if value != "" && !slices.Contains(allowed, value) {
+		errs.Add(field, CodeNotAllow, fmt.Sprintf("Value must be one of %v", allowed))
+	}
+}
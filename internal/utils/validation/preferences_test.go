@@ -0,0 +1,82 @@
+package validation
+
+import "testing"
+
+func TestAgeRange(t *testing.T) {
+	p := newTestPolicy(t)
+	age := func(v int) *int { return &v }
+
+	tests := []struct {
+		name      string
+		minAge    *int
+		maxAge    *int
+		wantField string
+	}{
+		{"no bounds", nil, nil, ""},
+		{"both bounds", age(20), age(30), ""},
+		{"equal bounds", age(25), age(25), ""},
+		{"min only", age(18), nil, ""},
+		{"max only", nil, age(maxPreferredAge), ""},
+		{"min below registration age", age(17), nil, "min_age"},
+		{"max above limit", nil, age(maxPreferredAge + 1), "max_age"},
+		{"min greater than max", age(30), age(20), "min_age"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs Errors
+			p.AgeRange(&errs, "min_age", "max_age", tt.minAge, tt.maxAge)
+
+			if tt.wantField == "" {
+				if !errs.Empty() {
+					t.Errorf("unexpected errors: %+v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.wantField || errs[0].Code != CodeInvalid {
+				t.Errorf("errors = %+v, want %s", errs, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestGenders(t *testing.T) {
+	p := newTestPolicy(t)
+
+	tests := []struct {
+		genders []string
+		want    string
+	}{
+		{nil, ""},
+		{[]string{"male", "female"}, ""},
+		{[]string{"male", "other"}, CodeNotAllow},
+		{[]string{""}, CodeNotAllow},
+	}
+
+	for _, tt := range tests {
+		if got := check(func(errs *Errors) { p.Genders(errs, "genders", tt.genders) }); got != tt.want {
+			t.Errorf("Genders(%v) = %q, want %q", tt.genders, got, tt.want)
+		}
+	}
+}
+
+func TestOneOf(t *testing.T) {
+	p := newTestPolicy(t)
+	allowed := []string{"friendship", "dating"}
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"dating", ""},
+		{"Dating", CodeNotAllow},
+		{"marriage", CodeNotAllow},
+	}
+
+	for _, tt := range tests {
+		if got := check(func(errs *Errors) { p.OneOf(errs, "intent", tt.value, allowed) }); got != tt.want {
+			t.Errorf("OneOf(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
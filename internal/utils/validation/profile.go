@@ -0,0 +1,75 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ограничения полей анкеты
+const (
+	maxAboutMeLength    = 1000
+	maxLookingForLength = 100
+	maxAvatarURLLength  = 2048
+	maxCityLength       = 100
+)
+
+// AboutMe проверяет длину текста "о себе". Пустое значение допустимо.
+func (p *Policy) AboutMe(errs *Errors, field, aboutMe string) {
+	if utf8.RuneCountInString(aboutMe) > maxAboutMeLength {
+		errs.Add(field, CodeTooLong, fmt.Sprintf("About me must be at most %d characters long", maxAboutMeLength))
+	}
+}
+
+// LookingFor проверяет длину поля "кого ищу". Пустое значение допустимо.
+func (p *Policy) LookingFor(errs *Errors, field, lookingFor string) {
+	if utf8.RuneCountInString(lookingFor) > maxLookingForLength {
+		errs.Add(field, CodeTooLong, fmt.Sprintf("Looking for must be at most %d characters long", maxLookingForLength))
+	}
+}
+
+// AvatarURL проверяет, что адрес аватара - абсолютный http(s) URL. Пустое значение удаляет аватар.
+func (p *Policy) AvatarURL(errs *Errors, field, avatarURL string) {
+	if avatarURL == "" {
+		return
+	}
+
+	if len(avatarURL) > maxAvatarURLLength {
+		errs.Add(field, CodeTooLong, fmt.Sprintf("Avatar URL must be at most %d characters long", maxAvatarURLLength))
+		return
+	}
+
+	u, err := url.Parse(avatarURL)
+	if err != nil || u.Host == "" || (!strings.EqualFold(u.Scheme, "https") && !strings.EqualFold(u.Scheme, "http")) {
+		errs.Add(field, CodeInvalid, "Avatar URL must be an absolute http or https URL")
+	}
+}
+
+// Location проверяет местоположение: координаты указываются обе или ни одной и лежат
+// в допустимых пределах. Пустой город допустим.
+func (p *Policy) Location(errs *Errors, field string, latitude, longitude *float64, city string) {
+	if (latitude == nil) != (longitude == nil) {
+		errs.Add(field, CodeRequired, "Latitude and longitude must be set together")
+	}
+	if latitude != nil && (*latitude < -90 || *latitude > 90) {
+		errs.Add(field+".latitude", CodeInvalid, "Latitude must be between -90 and 90")
+	}
+	if longitude != nil && (*longitude < -180 || *longitude > 180) {
+		errs.Add(field+".longitude", CodeInvalid, "Longitude must be between -180 and 180")
+	}
+	if utf8.RuneCountInString(city) > maxCityLength {
+		errs.Add(field+".city", CodeTooLong, fmt.Sprintf("City must be at most %d characters long", maxCityLength))
+	}
+}
+
+// HiddenFields проверяет, что скрывать просят только поля из allowed
+func (p *Policy) HiddenFields(errs *Errors, field string, hidden, allowed []string) {
+	for _, name := range hidden {
+		if !slices.Contains(allowed, name) {
+			errs.Add(field, CodeNotAllow, fmt.Sprintf("Field %q cannot be hidden", name))
+			return
+		}
+	}
+}
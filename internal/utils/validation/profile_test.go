@@ -0,0 +1,115 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAboutMeAndLookingFor(t *testing.T) {
+	p := newTestPolicy(t)
+
+	tests := []struct {
+		name     string
+		validate func(errs *Errors)
+		want     string
+	}{
+		{"about me empty", func(errs *Errors) { p.AboutMe(errs, "about_me", "") }, ""},
+		{"about me at limit", func(errs *Errors) { p.AboutMe(errs, "about_me", strings.Repeat("я", maxAboutMeLength)) }, ""},
+		{"about me too long", func(errs *Errors) { p.AboutMe(errs, "about_me", strings.Repeat("a", maxAboutMeLength+1)) }, CodeTooLong},
+		{"looking for at limit", func(errs *Errors) { p.LookingFor(errs, "looking_for", strings.Repeat("я", maxLookingForLength)) }, ""},
+		{"looking for too long", func(errs *Errors) { p.LookingFor(errs, "looking_for", strings.Repeat("a", maxLookingForLength+1)) }, CodeTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := check(tt.validate); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAvatarURL(t *testing.T) {
+	p := newTestPolicy(t)
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"", ""},
+		{"https://cdn.example.com/a.jpg", ""},
+		{"HTTP://cdn.example.com/a.jpg", ""},
+		{"/media/a.jpg", CodeInvalid},
+		{"javascript:alert(1)", CodeInvalid},
+		{"ftp://cdn.example.com/a.jpg", CodeInvalid},
+		{"https://", CodeInvalid},
+		{"https://cdn.example.com/" + strings.Repeat("a", maxAvatarURLLength), CodeTooLong},
+	}
+
+	for _, tt := range tests {
+		if got := check(func(errs *Errors) { p.AvatarURL(errs, "avatar_url", tt.url) }); got != tt.want {
+			t.Errorf("AvatarURL(%.40q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestLocation(t *testing.T) {
+	p := newTestPolicy(t)
+	float := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		latitude  *float64
+		longitude *float64
+		city      string
+		wantField string
+		wantCode  string
+	}{
+		{"both coordinates", float(55.75), float(37.62), "Москва", "", ""},
+		{"no coordinates", nil, nil, "Москва", "", ""},
+		{"bounds", float(-90), float(180), "", "", ""},
+		{"latitude only", float(55.75), nil, "", "location", CodeRequired},
+		{"longitude only", nil, float(37.62), "", "location", CodeRequired},
+		{"latitude out of range", float(90.5), float(0), "", "location.latitude", CodeInvalid},
+		{"longitude out of range", float(0), float(-180.5), "", "location.longitude", CodeInvalid},
+		{"city too long", nil, nil, strings.Repeat("г", maxCityLength+1), "location.city", CodeTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs Errors
+			p.Location(&errs, "location", tt.latitude, tt.longitude, tt.city)
+
+			if tt.wantField == "" {
+				if !errs.Empty() {
+					t.Errorf("unexpected errors: %+v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.wantField || errs[0].Code != tt.wantCode {
+				t.Errorf("errors = %+v, want %s/%s", errs, tt.wantField, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHiddenFields(t *testing.T) {
+	p := newTestPolicy(t)
+	allowed := []string{"age", "gender"}
+
+	tests := []struct {
+		hidden []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{"age"}, ""},
+		{[]string{"age", "gender"}, ""},
+		{[]string{"age", "email"}, CodeNotAllow},
+	}
+
+	for _, tt := range tests {
+		if got := check(func(errs *Errors) { p.HiddenFields(errs, "hidden_fields", tt.hidden, allowed) }); got != tt.want {
+			t.Errorf("HiddenFields(%v) = %q, want %q", tt.hidden, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package validation
+
+import "github.com/gin-gonic/gin"
+
+// FieldError ошибка проверки одного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors список ошибок по полям
+type Errors []FieldError
+
+// Add добавляет ошибку поля
+func (e *Errors) Add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Empty сообщает, что ошибок нет
+func (e Errors) Empty() bool {
+	return len(e) == 0
+}
+
+// Коды ошибок, на которые может ориентироваться фронтенд
+const (
+	CodeRequired = "required"
+	CodeInvalid  = "invalid"
+	CodeTooShort = "too_short"
+	CodeTooLong  = "too_long"
+	CodeBreached = "breached"
+	CodeTooYoung = "too_young"
+	CodeInFuture = "in_future"
+	CodeNotAllow = "not_allowed"
+	CodeTaken    = "taken"
+)
+
+// Has сообщает, есть ли ошибка для поля
+func (e Errors) Has(field string) bool {
+	for _, fieldError := range e {
+		if fieldError.Field == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Response тело ответа 422 со списком ошибок по полям
+func (e Errors) Response() gin.H {
+	return gin.H{
+		"error":  "Validation failed",
+		"fields": e,
+	}
+}
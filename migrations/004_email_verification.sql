@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+-- Уже существующие учетные записи считаем подтвержденными
+UPDATE users SET email_verified_at = now() WHERE email_verified_at IS NULL;
+
+-- Одноразовые токены подтверждения email (jti из подписанного токена)
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+    jti         TEXT PRIMARY KEY,
+    user_id     INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS email_verification_tokens_user_id_idx ON email_verification_tokens (user_id);
@@ -0,0 +1,9 @@
+-- Счетчики неудачных попыток входа по учетной записи ("email:...") и по IP ("ip:...")
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key              TEXT PRIMARY KEY,
+    failures         INTEGER     NOT NULL DEFAULT 0,
+    last_failure_at  TIMESTAMPTZ NOT NULL,
+    locked_until     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS login_attempts_last_failure_at_idx ON login_attempts (last_failure_at);
@@ -0,0 +1,17 @@
+-- TOTP (RFC 6238): секрет появляется при регистрации устройства,
+-- а 2FA включается только после подтверждения кодом (totp_enabled_at).
+-- totp_last_step - последний принятый временной шаг, защищает от повторного использования кода.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;
+
+-- Одноразовые коды восстановления (SHA-256 хэши)
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash   TEXT        NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS user_recovery_codes_user_id_idx ON user_recovery_codes (user_id);
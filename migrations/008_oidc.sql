@@ -0,0 +1,24 @@
+-- Внешние учетные записи (OpenID Connect), привязанные к пользователям
+CREATE TABLE IF NOT EXISTS user_identities (
+    provider    TEXT        NOT NULL,
+    subject     TEXT        NOT NULL,
+    user_id     INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email       TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_id_idx ON user_identities (user_id);
+
+-- Незавершенные попытки входа через провайдера: state, nonce и PKCE code_verifier
+CREATE TABLE IF NOT EXISTS oidc_auth_requests (
+    state          TEXT PRIMARY KEY,
+    provider       TEXT        NOT NULL,
+    nonce          TEXT        NOT NULL,
+    code_verifier  TEXT        NOT NULL,
+    expires_at     TIMESTAMPTZ NOT NULL
+);
+
+-- Пользователи, пришедшие через провайдера, могут не иметь пароля, даты рождения и пола
+ALTER TABLE users ALTER COLUMN date_of_birth DROP NOT NULL;
+ALTER TABLE users ALTER COLUMN gender DROP NOT NULL;
@@ -0,0 +1,16 @@
+-- Сессии (устройства) пользователя. id совпадает с family_id семейства refresh-токенов
+-- и передается в access-токене в claim "sid"
+CREATE TABLE IF NOT EXISTS sessions (
+    id            TEXT PRIMARY KEY,
+    user_id       INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    current_jti   TEXT        NOT NULL,
+    user_agent    TEXT        NOT NULL DEFAULT '',
+    ip            TEXT        NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL,
+    last_seen_at  TIMESTAMPTZ NOT NULL,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    revoked_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id);
+CREATE INDEX IF NOT EXISTS sessions_expires_at_idx ON sessions (expires_at);
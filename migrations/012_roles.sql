@@ -0,0 +1,2 @@
+-- Роли пользователей для разграничения доступа (см. internal/utils/rbac)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS roles TEXT[] NOT NULL DEFAULT '{user}';
@@ -0,0 +1,18 @@
+-- API-ключи (персональные токены) для ботов и интеграций.
+-- Хранится только SHA-256 хэш ключа, prefix - первые символы для отображения в списке.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name          TEXT        NOT NULL,
+    prefix        TEXT        NOT NULL,
+    key_hash      TEXT        NOT NULL UNIQUE,
+    scopes        TEXT[]      NOT NULL DEFAULT '{}',
+    created_by    INTEGER     NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    last_used_at  TIMESTAMPTZ,
+    last_used_ip  TEXT,
+    revoked_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
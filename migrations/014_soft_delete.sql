@@ -0,0 +1,7 @@
+-- Мягкое удаление учетной записи: deleted_at - когда пользователь запросил удаление,
+-- purged_at - когда по истечении срока восстановления данные были удалены, а запись обезличена
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS purged_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS users_pending_deletion_idx ON users (deleted_at)
+    WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
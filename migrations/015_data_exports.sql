@@ -0,0 +1,17 @@
+-- Выгрузки персональных данных. Архив собирается фоновой задачей:
+-- pending -> processing -> ready | failed; готовый архив удаляется после expires_at.
+CREATE TABLE IF NOT EXISTS data_exports (
+    id            TEXT PRIMARY KEY,
+    user_id       INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status        TEXT        NOT NULL DEFAULT 'pending',
+    file_path     TEXT,
+    size_bytes    BIGINT,
+    error         TEXT,
+    created_at    TIMESTAMPTZ NOT NULL,
+    started_at    TIMESTAMPTZ,
+    completed_at  TIMESTAMPTZ,
+    expires_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS data_exports_user_id_idx ON data_exports (user_id);
+CREATE INDEX IF NOT EXISTS data_exports_status_idx ON data_exports (status, created_at);
@@ -0,0 +1,12 @@
+-- Одноразовые ссылки для входа без пароля (jti из подписанного токена).
+-- nonce_hash - хэш nonce из cookie браузера, запросившего ссылку.
+CREATE TABLE IF NOT EXISTS magic_link_tokens (
+    jti         TEXT PRIMARY KEY,
+    user_id     INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    nonce_hash  TEXT        NOT NULL,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS magic_link_tokens_user_id_idx ON magic_link_tokens (user_id);
@@ -0,0 +1,33 @@
+-- Журнал событий безопасности: входы, регистрации, смены пароля, отзывы токенов, удаления.
+-- Таблица только для добавления: изменение и удаление записей запрещены триггером.
+CREATE TABLE IF NOT EXISTS security_events (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     INTEGER REFERENCES users (id),
+    actor_id    INTEGER REFERENCES users (id),
+    type        TEXT        NOT NULL,
+    ip          TEXT        NOT NULL DEFAULT '',
+    user_agent  TEXT        NOT NULL DEFAULT '',
+    request_id  TEXT        NOT NULL DEFAULT '',
+    details     JSONB       NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS security_events_user_id_idx ON security_events (user_id, id DESC);
+CREATE INDEX IF NOT EXISTS security_events_type_idx ON security_events (type, id DESC);
+CREATE INDEX IF NOT EXISTS security_events_created_at_idx ON security_events (created_at);
+
+CREATE OR REPLACE FUNCTION security_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'security_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS security_events_append_only ON security_events;
+CREATE TRIGGER security_events_append_only
+    BEFORE UPDATE OR DELETE ON security_events
+    FOR EACH ROW EXECUTE FUNCTION security_events_append_only();
+
+DROP TRIGGER IF EXISTS security_events_no_truncate ON security_events;
+CREATE TRIGGER security_events_no_truncate
+    BEFORE TRUNCATE ON security_events
+    FOR EACH STATEMENT EXECUTE FUNCTION security_events_append_only();
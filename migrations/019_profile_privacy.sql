@@ -0,0 +1,2 @@
+-- Настройки приватности анкеты: поля, скрытые от других пользователей
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS hidden_fields TEXT[] NOT NULL DEFAULT '{}';
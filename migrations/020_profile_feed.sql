@@ -0,0 +1,10 @@
+-- Индексы ленты анкет (keyset-пагинация по ключу сортировки и id)
+CREATE INDEX IF NOT EXISTS profiles_updated_at_id_idx ON profiles (updated_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS profiles_created_at_id_idx ON profiles (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS profiles_gender_updated_at_id_idx ON profiles (gender, updated_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS profiles_looking_for_idx ON profiles (looking_for);
+CREATE INDEX IF NOT EXISTS profiles_user_id_idx ON profiles (user_id);
+
+-- Фильтр и сортировка по возрасту идут по дате рождения активных учетных записей
+CREATE INDEX IF NOT EXISTS users_active_date_of_birth_idx ON users (date_of_birth, id)
+    WHERE deleted_at IS NULL;
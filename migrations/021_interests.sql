@@ -0,0 +1,74 @@
+-- Каталог интересов: категории, интересы и их синонимы
+CREATE TABLE IF NOT EXISTS interest_categories (
+    id    SERIAL PRIMARY KEY,
+    slug  TEXT NOT NULL UNIQUE,
+    name  TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS interests (
+    id           SERIAL PRIMARY KEY,
+    slug         TEXT    NOT NULL UNIQUE,
+    name         TEXT    NOT NULL,
+    category_id  INTEGER NOT NULL REFERENCES interest_categories (id)
+);
+
+CREATE INDEX IF NOT EXISTS interests_category_id_idx ON interests (category_id);
+CREATE UNIQUE INDEX IF NOT EXISTS interests_lower_name_idx ON interests (lower(name));
+
+-- Синонимы хранятся в нижнем регистре и однозначно указывают на интерес
+CREATE TABLE IF NOT EXISTS interest_synonyms (
+    synonym      TEXT PRIMARY KEY,
+    interest_id  INTEGER NOT NULL REFERENCES interests (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS interest_synonyms_interest_id_idx ON interest_synonyms (interest_id);
+
+-- Интересы анкеты
+CREATE TABLE IF NOT EXISTS profile_interests (
+    profile_id   INTEGER NOT NULL REFERENCES profiles (id) ON DELETE CASCADE,
+    interest_id  INTEGER NOT NULL REFERENCES interests (id) ON DELETE CASCADE,
+    PRIMARY KEY (profile_id, interest_id)
+);
+
+-- Фильтр ленты по интересам
+CREATE INDEX IF NOT EXISTS profile_interests_interest_id_idx ON profile_interests (interest_id, profile_id);
+
+-- Начальный каталог
+INSERT INTO interest_categories (slug, name) VALUES
+    ('sport', 'Спорт'),
+    ('music', 'Музыка'),
+    ('creativity', 'Творчество'),
+    ('travel', 'Путешествия'),
+    ('games', 'Игры')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO interests (slug, name, category_id)
+SELECT v.slug, v.name, c.id
+FROM (VALUES
+    ('running', 'Бег', 'sport'),
+    ('hiking', 'Походы', 'sport'),
+    ('yoga', 'Йога', 'sport'),
+    ('rock', 'Рок', 'music'),
+    ('jazz', 'Джаз', 'music'),
+    ('photography', 'Фотография', 'creativity'),
+    ('drawing', 'Рисование', 'creativity'),
+    ('road-trips', 'Автопутешествия', 'travel'),
+    ('board-games', 'Настольные игры', 'games'),
+    ('video-games', 'Видеоигры', 'games')
+) AS v (slug, name, category)
+JOIN interest_categories c ON c.slug = v.category
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO interest_synonyms (synonym, interest_id)
+SELECT v.synonym, i.id
+FROM (VALUES
+    ('jogging', 'running'),
+    ('пробежки', 'running'),
+    ('трекинг', 'hiking'),
+    ('trekking', 'hiking'),
+    ('фото', 'photography'),
+    ('настолки', 'board-games'),
+    ('gaming', 'video-games')
+) AS v (synonym, interest)
+JOIN interests i ON i.slug = v.interest
+ON CONFLICT (synonym) DO NOTHING;
@@ -0,0 +1,15 @@
+-- Местоположение анкеты. Точные координаты видит только владелец,
+-- остальным показываются город и округленное расстояние.
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS city TEXT NOT NULL DEFAULT '';
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+ALTER TABLE profiles DROP CONSTRAINT IF EXISTS profiles_location_check;
+ALTER TABLE profiles ADD CONSTRAINT profiles_location_check CHECK (
+    (latitude IS NULL AND longitude IS NULL)
+    OR (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
+
+-- Предварительный отбор по ограничивающему прямоугольнику перед расчетом расстояния
+CREATE INDEX IF NOT EXISTS profiles_latitude_longitude_idx ON profiles (latitude, longitude)
+    WHERE latitude IS NOT NULL;
@@ -0,0 +1,38 @@
+-- Галерея фотографий анкеты. Файлы лежат в хранилище (локальный каталог или S3),
+-- в variants - ключи, адреса и размеры копий.
+CREATE TABLE IF NOT EXISTS profile_photos (
+    id          SERIAL PRIMARY KEY,
+    profile_id  INTEGER     NOT NULL REFERENCES profiles (id) ON DELETE CASCADE,
+    position    INTEGER     NOT NULL,
+    is_primary  BOOLEAN     NOT NULL DEFAULT false,
+    variants    JSONB       NOT NULL,
+    avatar_url  TEXT        NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS profile_photos_profile_id_idx ON profile_photos (profile_id, position);
+
+-- У анкеты не больше одной основной фотографии
+CREATE UNIQUE INDEX IF NOT EXISTS profile_photos_primary_idx ON profile_photos (profile_id) WHERE is_primary;
+
+-- Файлы удаленных фотографий, которые фоновая задача еще не удалила из хранилища
+CREATE TABLE IF NOT EXISTS media_deletions (
+    key         TEXT PRIMARY KEY,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Фотографии удаляются и пользователем, и каскадно при окончательном удалении учетной записи:
+-- в обоих случаях файлы ставятся в очередь на удаление
+CREATE OR REPLACE FUNCTION profile_photos_queue_media_deletion() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO media_deletions (key)
+    SELECT variant->>'key' FROM jsonb_array_elements(OLD.variants) AS variant
+    ON CONFLICT DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS profile_photos_queue_media_deletion ON profile_photos;
+CREATE TRIGGER profile_photos_queue_media_deletion
+    AFTER DELETE ON profile_photos
+    FOR EACH ROW EXECUTE FUNCTION profile_photos_queue_media_deletion();
@@ -0,0 +1,14 @@
+-- Предпочтения подбора: кого пользователь хочет видеть в ленте. NULL и пустые списки -
+-- без ограничения; нет строки - нет ограничений. Анкета показывается только при взаимном
+-- совпадении: кандидат подходит смотрящему, а смотрящий - кандидату.
+CREATE TABLE IF NOT EXISTS discovery_preferences (
+    user_id          INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    min_age          INTEGER,
+    max_age          INTEGER,
+    genders          TEXT[]      NOT NULL DEFAULT '{}',
+    max_distance_km  INTEGER,
+    intent           TEXT        NOT NULL DEFAULT '',
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (min_age IS NULL OR max_age IS NULL OR min_age <= max_age),
+    CHECK (max_distance_km IS NULL OR max_distance_km > 0)
+);
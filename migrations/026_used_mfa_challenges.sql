@@ -0,0 +1,10 @@
+-- Погашенные промежуточные токены входа с 2FA (jti): каждый принимается один раз.
+-- Запись нужна только до истечения токена.
+CREATE TABLE IF NOT EXISTS used_mfa_challenges (
+    jti         TEXT PRIMARY KEY,
+    user_id     INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS used_mfa_challenges_expires_at_idx ON used_mfa_challenges (expires_at);
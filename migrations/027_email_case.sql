@@ -0,0 +1,5 @@
+-- Email хранится в нижнем регистре и сравнивается без учета регистра (lower(email)).
+-- Адреса, отличающиеся только регистром, нужно разобрать вручную до применения.
+UPDATE users SET email = lower(email) WHERE email <> lower(email);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_idx ON users (lower(email));
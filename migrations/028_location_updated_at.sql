@@ -0,0 +1,3 @@
+-- Когда координаты анкеты последний раз менялись: частая смена местоположения
+-- позволила бы вычислить положение других пользователей по расстоянию до них
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS location_updated_at TIMESTAMPTZ;
@@ -0,0 +1,33 @@
+-- Журнал безопасности остается только для добавления, но записи удаленных учетных записей
+-- можно обезличить: очистить IP, User-Agent и подробности, не меняя остальных полей.
+CREATE OR REPLACE FUNCTION security_events_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND NEW.id = OLD.id
+        AND NEW.user_id IS NOT DISTINCT FROM OLD.user_id
+        AND NEW.actor_id IS NOT DISTINCT FROM OLD.actor_id
+        AND NEW.type = OLD.type
+        AND NEW.request_id = OLD.request_id
+        AND NEW.created_at = OLD.created_at
+        AND NEW.ip = ''
+        AND NEW.user_agent = ''
+        AND NEW.details = '{}'::jsonb THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION 'security_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Неудачные входы хранили email в открытом виде: известной учетной записи достаточно
+-- user_id, для остальных адрес заменяется хэшем
+ALTER TABLE security_events DISABLE TRIGGER security_events_append_only;
+
+UPDATE security_events SET details = details - 'email'
+WHERE details ? 'email' AND user_id IS NOT NULL;
+
+UPDATE security_events SET details = (details - 'email')
+    || jsonb_build_object('email_hash', encode(sha256(convert_to(lower(trim(details->>'email')), 'UTF8')), 'hex'))
+WHERE details ? 'email';
+
+ALTER TABLE security_events ENABLE TRIGGER security_events_append_only;